@@ -0,0 +1,11 @@
+package handler
+
+import (
+	"chess/handlers"
+	"net/http"
+)
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handlers.HandleAnalyze(w, r)
+}
+
@@ -0,0 +1,178 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// The binary encoding is an occupancy bitboard followed by one nibble per
+// occupied square (colour in the high bit, PieceType below it) in square
+// order, a flags byte, the en passant square and Chess960 rook files when
+// present, and the two move counters as uvarints. The starting position
+// takes 27 bytes.
+const (
+	binaryWhiteToMove = 1 << iota
+	binaryWhiteKingSide
+	binaryWhiteQueenSide
+	binaryBlackKingSide
+	binaryBlackQueenSide
+	binaryEnPassant
+	binaryChess960
+)
+
+var errShortBinary = errors.New("binary position is truncated")
+
+func (b *Board) MarshalBinary() ([]byte, error) {
+	return b.AppendBinary(make([]byte, 0, 32))
+}
+
+// AppendBinary appends the binary encoding of the position to data.
+func (b *Board) AppendBinary(data []byte) ([]byte, error) {
+	occupied := b.AllPieces()
+	data = binary.LittleEndian.AppendUint64(data, occupied)
+
+	var nibbles byte
+	count := 0
+	for squares := occupied; squares != 0; squares &= squares - 1 {
+		piece, color, _ := b.PieceAt(bits.TrailingZeros64(squares))
+		code := byte(piece) | byte(color)<<3
+		if count%2 == 0 {
+			nibbles = code
+		} else {
+			data = append(data, nibbles|code<<4)
+		}
+		count++
+	}
+	if count%2 == 1 {
+		data = append(data, nibbles)
+	}
+
+	var flags byte
+	if b.WhiteToMove {
+		flags |= binaryWhiteToMove
+	}
+	if b.WhiteKingSideCastle {
+		flags |= binaryWhiteKingSide
+	}
+	if b.WhiteQueenSideCastle {
+		flags |= binaryWhiteQueenSide
+	}
+	if b.BlackKingSideCastle {
+		flags |= binaryBlackKingSide
+	}
+	if b.BlackQueenSideCastle {
+		flags |= binaryBlackQueenSide
+	}
+	if b.EnPassantSquare >= 0 {
+		flags |= binaryEnPassant
+	}
+	if b.Chess960 {
+		flags |= binaryChess960
+	}
+	data = append(data, flags)
+
+	if b.EnPassantSquare >= 0 {
+		data = append(data, byte(b.EnPassantSquare))
+	}
+	if b.Chess960 {
+		files := b.CastlingRookFiles
+		data = binary.LittleEndian.AppendUint16(data, uint16(files[White][KingSide]|files[White][QueenSide]<<3|
+			files[Black][KingSide]<<6|files[Black][QueenSide]<<9))
+	}
+
+	data = binary.AppendUvarint(data, uint64(b.HalfmoveClock))
+	data = binary.AppendUvarint(data, uint64(b.FullmoveNumber))
+	return data, nil
+}
+
+// UnmarshalBinary decodes a position written by MarshalBinary and checks it
+// the same way ParseFEN does. The board is left untouched on error.
+func (b *Board) UnmarshalBinary(data []byte) error {
+	var board Board
+	if len(data) < 8 {
+		return errShortBinary
+	}
+	occupied := binary.LittleEndian.Uint64(data)
+	data = data[8:]
+
+	count := bits.OnesCount64(occupied)
+	if len(data) < (count+1)/2 {
+		return errShortBinary
+	}
+	i := 0
+	for squares := occupied; squares != 0; squares &= squares - 1 {
+		code := data[i/2] >> (4 * (i % 2)) & 0xf
+		piece, color := PieceType(code&0x7), Color(code>>3)
+		if piece > King {
+			return fmt.Errorf("invalid piece code %d in binary position", code)
+		}
+		*board.GetBitboard(piece, color) |= squares & -squares
+		i++
+	}
+	data = data[(count+1)/2:]
+
+	if len(data) < 1 {
+		return errShortBinary
+	}
+	flags := data[0]
+	data = data[1:]
+	board.WhiteToMove = flags&binaryWhiteToMove != 0
+	board.WhiteKingSideCastle = flags&binaryWhiteKingSide != 0
+	board.WhiteQueenSideCastle = flags&binaryWhiteQueenSide != 0
+	board.BlackKingSideCastle = flags&binaryBlackKingSide != 0
+	board.BlackQueenSideCastle = flags&binaryBlackQueenSide != 0
+	board.Chess960 = flags&binaryChess960 != 0
+
+	board.EnPassantSquare = -1
+	if flags&binaryEnPassant != 0 {
+		if len(data) < 1 {
+			return errShortBinary
+		}
+		if data[0] > 63 {
+			return fmt.Errorf("invalid en passant square %d in binary position", data[0])
+		}
+		board.EnPassantSquare = int(data[0])
+		data = data[1:]
+	}
+
+	board.CastlingRookFiles = [2][2]int{{7, 0}, {7, 0}}
+	if board.Chess960 {
+		if len(data) < 2 {
+			return errShortBinary
+		}
+		files := int(binary.LittleEndian.Uint16(data))
+		board.CastlingRookFiles = [2][2]int{
+			White: {KingSide: files & 7, QueenSide: files >> 3 & 7},
+			Black: {KingSide: files >> 6 & 7, QueenSide: files >> 9 & 7},
+		}
+		data = data[2:]
+	}
+
+	halfmoveClock, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errShortBinary
+	}
+	data = data[n:]
+	fullmoveNumber, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errShortBinary
+	}
+	if n != len(data) {
+		return fmt.Errorf("%d trailing bytes after binary position", len(data)-n)
+	}
+	if halfmoveClock > 1<<20 || fullmoveNumber < 1 || fullmoveNumber > 1<<20 {
+		return fmt.Errorf("invalid move counters %d %d in binary position", halfmoveClock, fullmoveNumber)
+	}
+	board.HalfmoveClock = int(halfmoveClock)
+	board.FullmoveNumber = int(fullmoveNumber)
+
+	if err := board.validatePosition(); err != nil {
+		return err
+	}
+	board.Hash = board.ComputeHash()
+
+	*b = board
+	return nil
+}
@@ -13,6 +13,13 @@ const (
 	Black Color = 0
 )
 
+func (c Color) String() string {
+	if c == White {
+		return "white"
+	}
+	return "black"
+}
+
 const (
 	Pawn PieceType = iota
 	Rook
@@ -52,6 +59,14 @@ type Board struct {
 	WhiteToMove                                                                          bool
 	WhiteKingSideCastle, WhiteQueenSideCastle, BlackKingSideCastle, BlackQueenSideCastle bool
 	EnPassantSquare                                                                      int
+	HalfmoveClock, FullmoveNumber                                                        int
+	Hash                                                                                 uint64
+
+	// Chess960 switches castling moves to king-takes-rook encoding.
+	// CastlingRookFiles holds the file of each side's castling rook, indexed
+	// by color and then KingSide or QueenSide.
+	Chess960          bool
+	CastlingRookFiles [2][2]int
 }
 
 func (b *Board) PieceAt(square int) (PieceType, Color, bool) {
@@ -146,75 +161,47 @@ func (b *Board) AllPieces() uint64 {
 	return b.WhitePieces() | b.BlackPieces()
 }
 
-func NewBoardFromFEN(fen string) *Board {
-
-	board := new(Board)
-	parts := strings.Split(fen, " ")
+func (b *Board) Clone() *Board {
+	clone := *b
+	return &clone
+}
 
-	row := 8
-	col := 1
+// Equal reports whether both boards hold the same position with the same
+// halfmove clock and fullmove number.
+func (b *Board) Equal(other *Board) bool {
+	return b.EqualPosition(other) &&
+		b.HalfmoveClock == other.HalfmoveClock && b.FullmoveNumber == other.FullmoveNumber
+}
 
-	for i := 0; i < len(parts[0]); i++ {
-		if parts[0][i] == '/' {
-			row--
-			col = 1
-		} else if parts[0][i] >= '1' && parts[0][i] <= '8' {
-			col += int(parts[0][i] - '0')
-		} else {
-			squareIndex := (row-1)*8 + (col - 1)
-
-			switch parts[0][i] {
-			case 'P':
-				board.WhitePawns |= (1 << squareIndex)
-			case 'p':
-				board.BlackPawns |= (1 << squareIndex)
-			case 'N':
-				board.WhiteKnights |= (1 << squareIndex)
-			case 'n':
-				board.BlackKnights |= (1 << squareIndex)
-			case 'B':
-				board.WhiteBishops |= (1 << squareIndex)
-			case 'b':
-				board.BlackBishops |= (1 << squareIndex)
-			case 'R':
-				board.WhiteRooks |= (1 << squareIndex)
-			case 'r':
-				board.BlackRooks |= (1 << squareIndex)
-			case 'Q':
-				board.WhiteQueens |= (1 << squareIndex)
-			case 'q':
-				board.BlackQueens |= (1 << squareIndex)
-			case 'K':
-				board.WhiteKing |= (1 << squareIndex)
-			case 'k':
-				board.BlackKing |= (1 << squareIndex)
+// EqualPosition is Equal without the move counters. Rook files only count
+// for castling rights that are still held.
+func (b *Board) EqualPosition(other *Board) bool {
+	if b.WhitePawns != other.WhitePawns || b.WhiteKnights != other.WhiteKnights ||
+		b.WhiteBishops != other.WhiteBishops || b.WhiteRooks != other.WhiteRooks ||
+		b.WhiteQueens != other.WhiteQueens || b.WhiteKing != other.WhiteKing ||
+		b.BlackPawns != other.BlackPawns || b.BlackKnights != other.BlackKnights ||
+		b.BlackBishops != other.BlackBishops || b.BlackRooks != other.BlackRooks ||
+		b.BlackQueens != other.BlackQueens || b.BlackKing != other.BlackKing {
+		return false
+	}
+	if b.WhiteToMove != other.WhiteToMove || b.EnPassantSquare != other.EnPassantSquare ||
+		b.Chess960 != other.Chess960 {
+		return false
+	}
+	for _, color := range [2]Color{White, Black} {
+		for side := KingSide; side <= QueenSide; side++ {
+			right := *b.castlingRight(color, side)
+			if right != *other.castlingRight(color, side) {
+				return false
+			}
+			if right && b.CastlingRookFiles[color][side] != other.CastlingRookFiles[color][side] {
+				return false
 			}
-
-			col++
 		}
 	}
-
-	if len(parts) > 1 {
-		board.WhiteToMove = (parts[1] == "w")
-	}
-
-	if len(parts) > 2 {
-		board.WhiteKingSideCastle = strings.Contains(parts[2], "K")
-		board.WhiteQueenSideCastle = strings.Contains(parts[2], "Q")
-		board.BlackKingSideCastle = strings.Contains(parts[2], "k")
-		board.BlackQueenSideCastle = strings.Contains(parts[2], "q")
-	}
-
-	if len(parts) > 3 && parts[3] != "-" {
-		file := int(parts[3][0] - 'a')
-		rank := int(parts[3][1] - '1')
-		board.EnPassantSquare = rank*8 + file
-	} else {
-		board.EnPassantSquare = -1
-	}
-
-	return board
+	return true
 }
+
 func (b *Board) MakeMove(move Move) UndoMoveInfo {
 	undoInfo := UndoMoveInfo{
 		PreviousEnPassantSquare: b.EnPassantSquare,
@@ -222,9 +209,13 @@ func (b *Board) MakeMove(move Move) UndoMoveInfo {
 		WhiteQueenSideCastle:    b.WhiteQueenSideCastle,
 		BlackKingSideCastle:     b.BlackKingSideCastle,
 		BlackQueenSideCastle:    b.BlackQueenSideCastle,
+		PreviousHalfmoveClock:   b.HalfmoveClock,
+		PreviousHash:            b.Hash,
 		CapturedPieceType:       NoPieceType,
 	}
 
+	b.Hash ^= b.castlingHashKey() ^ b.epHashKey()
+
 	from := move.From()
 	to := move.To()
 	flag := move.Flag()
@@ -253,10 +244,12 @@ func (b *Board) MakeMove(move Move) UndoMoveInfo {
 				capturedPawnSquare = int(to) + 8
 			}
 			*b.GetBitboard(Pawn, enemyColor) &^= (uint64(1) << capturedPawnSquare)
+			b.Hash ^= zobristPieces[enemyColor][Pawn][capturedPawnSquare]
 		} else {
 			capturedPiece, _, _ := b.PieceAt(int(to))
 			undoInfo.CapturedPieceType = capturedPiece
 			*b.GetBitboard(capturedPiece, enemyColor) &^= toBit
+			b.Hash ^= zobristPieces[enemyColor][capturedPiece][to]
 		}
 	}
 
@@ -276,22 +269,18 @@ func (b *Board) MakeMove(move Move) UndoMoveInfo {
 			promoPiece = Knight
 		}
 		*b.GetBitboard(promoPiece, color) |= toBit
+		b.Hash ^= zobristPieces[color][Pawn][from] ^ zobristPieces[color][promoPiece][to]
+	} else if flag == FlagKingCastle || flag == FlagQueenCastle {
+		side := castlingSide(flag)
+		kingTo, rookTo := castlingTargets(color, side)
+		rookFrom := b.castlingRookSquare(color, side)
+		b.moveCastlingPieces(color, side, int(from), false)
+		b.Hash ^= zobristPieces[color][King][from] ^ zobristPieces[color][King][kingTo]
+		b.Hash ^= zobristPieces[color][Rook][rookFrom] ^ zobristPieces[color][Rook][rookTo]
 	} else {
+		b.Hash ^= zobristPieces[color][movingPiece][from] ^ zobristPieces[color][movingPiece][to]
+
 		switch flag {
-		case FlagKingCastle:
-			*b.GetBitboard(King, color) ^= fromToMask
-			if color == White {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << H1) | (uint64(1) << F1)
-			} else {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << H8) | (uint64(1) << F8)
-			}
-		case FlagQueenCastle:
-			*b.GetBitboard(King, color) ^= fromToMask
-			if color == White {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << A1) | (uint64(1) << D1)
-			} else {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << A8) | (uint64(1) << D8)
-			}
 		case FlagDoublePawn:
 			*b.GetBitboard(Pawn, color) ^= fromToMask
 			if color == White {
@@ -305,42 +294,36 @@ func (b *Board) MakeMove(move Move) UndoMoveInfo {
 	}
 
 	if movingPiece == King {
-		if color == White {
-			b.WhiteKingSideCastle = false
-			b.WhiteQueenSideCastle = false
-		} else {
-			b.BlackKingSideCastle = false
-			b.BlackQueenSideCastle = false
-		}
+		*b.castlingRight(color, KingSide) = false
+		*b.castlingRight(color, QueenSide) = false
 	}
 
-	if from == H1 {
-		b.WhiteKingSideCastle = false
-	}
-	if from == A1 {
-		b.WhiteQueenSideCastle = false
-	}
-	if from == H8 {
-		b.BlackKingSideCastle = false
-	}
-	if from == A8 {
-		b.BlackQueenSideCastle = false
+	for _, c := range [2]Color{White, Black} {
+		for side := KingSide; side <= QueenSide; side++ {
+			if right := b.castlingRight(c, side); *right {
+				rookSquare := uint16(b.castlingRookSquare(c, side))
+				if from == rookSquare || to == rookSquare {
+					*right = false
+				}
+			}
+		}
 	}
 
-	if to == H8 && undoInfo.CapturedPieceType == Rook {
-		b.BlackKingSideCastle = false
-	}
-	if to == A8 && undoInfo.CapturedPieceType == Rook {
-		b.BlackQueenSideCastle = false
-	}
-	if to == H1 && undoInfo.CapturedPieceType == Rook {
-		b.WhiteKingSideCastle = false
+	if movingPiece == Pawn || undoInfo.CapturedPieceType != NoPieceType {
+		b.HalfmoveClock = 0
+	} else {
+		b.HalfmoveClock++
 	}
-	if to == A1 && undoInfo.CapturedPieceType == Rook {
-		b.WhiteQueenSideCastle = false
+	if color == Black {
+		b.FullmoveNumber++
 	}
 
 	b.WhiteToMove = !b.WhiteToMove
+	b.Hash ^= zobristBlackToMove ^ b.castlingHashKey() ^ b.epHashKey()
+
+	if debugHash {
+		b.checkHash()
+	}
 
 	return undoInfo
 }
@@ -366,6 +349,11 @@ func (b *Board) UndoMove(move Move, undoInfo UndoMoveInfo) {
 	b.WhiteQueenSideCastle = undoInfo.WhiteQueenSideCastle
 	b.BlackKingSideCastle = undoInfo.BlackKingSideCastle
 	b.BlackQueenSideCastle = undoInfo.BlackQueenSideCastle
+	b.HalfmoveClock = undoInfo.PreviousHalfmoveClock
+	b.Hash = undoInfo.PreviousHash
+	if color == Black {
+		b.FullmoveNumber--
+	}
 
 	if flag >= FlagPromoKnight {
 		*b.GetBitboard(Pawn, color) |= fromBit
@@ -382,25 +370,10 @@ func (b *Board) UndoMove(move Move, undoInfo UndoMoveInfo) {
 			promoPiece = Knight
 		}
 		*b.GetBitboard(promoPiece, color) &^= toBit
+	} else if flag == FlagKingCastle || flag == FlagQueenCastle {
+		b.moveCastlingPieces(color, castlingSide(flag), int(from), true)
 	} else {
-		switch flag {
-		case FlagKingCastle:
-			*b.GetBitboard(King, color) ^= fromToMask
-			if color == White {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << H1) | (uint64(1) << F1)
-			} else {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << H8) | (uint64(1) << F8)
-			}
-		case FlagQueenCastle:
-			*b.GetBitboard(King, color) ^= fromToMask
-			if color == White {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << A1) | (uint64(1) << D1)
-			} else {
-				*b.GetBitboard(Rook, color) ^= (uint64(1) << A8) | (uint64(1) << D8)
-			}
-		default:
-			*b.GetBitboard(undoInfo.MovingPieceType, color) ^= fromToMask
-		}
+		*b.GetBitboard(undoInfo.MovingPieceType, color) ^= fromToMask
 	}
 
 	if undoInfo.CapturedPieceType != NoPieceType {
@@ -421,9 +394,23 @@ func (b *Board) UndoMove(move Move, undoInfo UndoMoveInfo) {
 		}
 		*b.GetBitboard(undoInfo.CapturedPieceType, enemyColor) |= (uint64(1) << capturedSquare)
 	}
+
+	if debugHash {
+		b.checkHash()
+	}
 }
 
 func (b *Board) ToFEN() string {
+	return b.toFEN(false)
+}
+
+// ToShredderFEN is ToFEN with castling rights written as rook files
+// ("HAha" rather than "KQkq").
+func (b *Board) ToShredderFEN() string {
+	return b.toFEN(true)
+}
+
+func (b *Board) toFEN(shredder bool) string {
 	var fen strings.Builder
 	
 	for rank := 7; rank >= 0; rank-- {
@@ -476,22 +463,7 @@ func (b *Board) ToFEN() string {
 		fen.WriteString(" b ")
 	}
 	
-	castling := ""
-	if b.WhiteKingSideCastle {
-		castling += "K"
-	}
-	if b.WhiteQueenSideCastle {
-		castling += "Q"
-	}
-	if b.BlackKingSideCastle {
-		castling += "k"
-	}
-	if b.BlackQueenSideCastle {
-		castling += "q"
-	}
-	if castling == "" {
-		castling = "-"
-	}
+	castling := b.castlingString(shredder)
 	fen.WriteString(castling)
 	
 	fen.WriteString(" ")
@@ -503,7 +475,7 @@ func (b *Board) ToFEN() string {
 		fen.WriteString("-")
 	}
 	
-	fen.WriteString(" 0 1")
+	fen.WriteString(fmt.Sprintf(" %d %d", b.HalfmoveClock, b.FullmoveNumber))
 	
 	return fen.String()
 }
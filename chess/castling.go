@@ -0,0 +1,164 @@
+package chess
+
+import "math/bits"
+
+// Castling sides, used to index Board.CastlingRookFiles.
+const (
+	KingSide  = 0
+	QueenSide = 1
+)
+
+func (b *Board) castlingRight(color Color, side int) *bool {
+	if color == White {
+		if side == KingSide {
+			return &b.WhiteKingSideCastle
+		}
+		return &b.WhiteQueenSideCastle
+	}
+	if side == KingSide {
+		return &b.BlackKingSideCastle
+	}
+	return &b.BlackQueenSideCastle
+}
+
+func backRank(color Color) int {
+	if color == White {
+		return 0
+	}
+	return 56
+}
+
+func (b *Board) castlingRookSquare(color Color, side int) int {
+	return backRank(color) + b.CastlingRookFiles[color][side]
+}
+
+// castlingTargets returns where the king and rook end up. They are the same
+// squares as in standard chess wherever the pieces started.
+func castlingTargets(color Color, side int) (kingTo, rookTo int) {
+	if side == KingSide {
+		return backRank(color) + 6, backRank(color) + 5
+	}
+	return backRank(color) + 2, backRank(color) + 3
+}
+
+func castlingSide(flag uint16) int {
+	if flag == FlagQueenCastle {
+		return QueenSide
+	}
+	return KingSide
+}
+
+// castlingMove encodes castling as a king move to its destination square in
+// standard chess and as the king capturing its own rook in Chess960, where the
+// king may not move at all or may land on a square it already passes through.
+func (b *Board) castlingMove(color Color, side int) Move {
+	kingFrom := bits.TrailingZeros64(*b.GetBitboard(King, color))
+	to, _ := castlingTargets(color, side)
+	if b.Chess960 {
+		to = b.castlingRookSquare(color, side)
+	}
+	flag := uint16(FlagKingCastle)
+	if side == QueenSide {
+		flag = FlagQueenCastle
+	}
+	return NewMove(uint16(kingFrom), uint16(to), flag)
+}
+
+// moveCastlingPieces moves the king from kingFrom and the castling rook to
+// their destinations, or back again when undo is set. Both pieces are lifted
+// before either is placed since their squares may overlap in Chess960.
+func (b *Board) moveCastlingPieces(color Color, side int, kingFrom int, undo bool) {
+	rookFrom := b.castlingRookSquare(color, side)
+	kingTo, rookTo := castlingTargets(color, side)
+	if undo {
+		kingFrom, kingTo = kingTo, kingFrom
+		rookFrom, rookTo = rookTo, rookFrom
+	}
+
+	king, rook := b.GetBitboard(King, color), b.GetBitboard(Rook, color)
+	*king &^= uint64(1) << kingFrom
+	*rook &^= uint64(1) << rookFrom
+	*king |= uint64(1) << kingTo
+	*rook |= uint64(1) << rookTo
+}
+
+// castlingAllowed reports whether color may castle on side given the squares
+// the enemy attacks. Every square between the king and its destination and
+// between the rook and its destination must be empty apart from the two
+// castling pieces, and the king may not start on, cross or land on an
+// attacked square.
+func (b *Board) castlingAllowed(color Color, side int, occupied, danger uint64) bool {
+	if !*b.castlingRight(color, side) {
+		return false
+	}
+
+	kingFrom := bits.TrailingZeros64(*b.GetBitboard(King, color))
+	rookFrom := b.castlingRookSquare(color, side)
+	kingTo, rookTo := castlingTargets(color, side)
+
+	kingPath := betweenMasks[kingFrom][kingTo] | uint64(1)<<kingFrom | uint64(1)<<kingTo
+	rookPath := betweenMasks[rookFrom][rookTo] | uint64(1)<<rookTo
+	castlingPieces := uint64(1)<<kingFrom | uint64(1)<<rookFrom
+
+	if occupied&(kingPath|rookPath)&^castlingPieces != 0 || danger&kingPath != 0 {
+		return false
+	}
+	if !b.Chess960 {
+		return true
+	}
+
+	// In Chess960 the castling rook can shield the king's destination from an
+	// enemy rook or queen further along the back rank.
+	enemyColor := White
+	if color == White {
+		enemyColor = Black
+	}
+	enemyRooks := *b.GetBitboard(Rook, enemyColor) | *b.GetBitboard(Queen, enemyColor)
+	return RookAttacks(kingTo, occupied&^castlingPieces)&enemyRooks == 0
+}
+
+// castlingDanger returns every square attacked by the side not to move, for
+// callers that have not already worked it out.
+func (b *Board) castlingDanger(color Color) (occupied, danger uint64) {
+	enemyColor := White
+	if color == White {
+		enemyColor = Black
+	}
+	us, them := b.sidePieces(color), b.sidePieces(enemyColor)
+	occupied = us.all | them.all
+	return occupied, attackedSquares(them, enemyColor, occupied&^us.king)
+}
+
+// castlingString formats the castling rights for a FEN as rook files when
+// shredder is set and as X-FEN otherwise, which only falls back to the file
+// letter when another rook stands further out on the same side, so that K or
+// Q would name the wrong rook.
+func (b *Board) castlingString(shredder bool) string {
+	var castling []byte
+	for _, color := range [2]Color{White, Black} {
+		rooks := *b.GetBitboard(Rook, color) >> backRank(color) & 0xff
+		for side := KingSide; side <= QueenSide; side++ {
+			if !*b.castlingRight(color, side) {
+				continue
+			}
+			file := b.CastlingRookFiles[color][side]
+			outer := rooks &^ (uint64(2)<<file - 1)
+			if side == QueenSide {
+				outer = rooks & (uint64(1)<<file - 1)
+			}
+
+			c := byte("KQ"[side])
+			if shredder || b.Chess960 && outer != 0 {
+				c = 'A' + byte(file)
+			}
+			if color == Black {
+				c |= 0x20
+			}
+			castling = append(castling, c)
+		}
+	}
+	if len(castling) == 0 {
+		return "-"
+	}
+	return string(castling)
+}
@@ -0,0 +1,56 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// Chess960StartingFEN returns Chess960 starting position n (0-959) in
+// Scharnagl numbering as a Shredder-FEN, so that ParseFEN switches the board
+// to Chess960 castling. Position 518 is the standard starting position.
+func Chess960StartingFEN(n int) (string, error) {
+	if n < 0 || n > 959 {
+		return "", fmt.Errorf("invalid Chess960 position %d: expected 0 to 959", n)
+	}
+
+	var rank [8]byte
+	rank[2*(n%4)+1] = 'b'
+	n /= 4
+	rank[2*(n%4)] = 'b'
+	n /= 4
+	placeOnEmpty(&rank, n%6, 'q')
+	n /= 6
+	knights := chess960Knights[n]
+	placeOnEmpty(&rank, knights[1], 'n')
+	placeOnEmpty(&rank, knights[0], 'n')
+	placeOnEmpty(&rank, 0, 'r')
+	placeOnEmpty(&rank, 0, 'k')
+	placeOnEmpty(&rank, 0, 'r')
+
+	black := string(rank[:])
+	white := strings.ToUpper(black)
+	rooks := []byte{
+		'A' + byte(strings.LastIndexByte(white, 'R')),
+		'A' + byte(strings.IndexByte(white, 'R')),
+	}
+	castling := string(rooks) + strings.ToLower(string(rooks))
+
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w %s - 0 1", black, white, castling), nil
+}
+
+func placeOnEmpty(rank *[8]byte, index int, piece byte) {
+	for file := range rank {
+		if rank[file] != 0 {
+			continue
+		}
+		if index == 0 {
+			rank[file] = piece
+			return
+		}
+		index--
+	}
+}
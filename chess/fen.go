@@ -0,0 +1,286 @@
+package chess
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// MustParseFEN is like ParseFEN but panics if fen is invalid. It is meant for
+// FENs known to be valid, such as StartingFEN; use ParseFEN for input.
+func MustParseFEN(fen string) *Board {
+	board, err := ParseFEN(fen)
+	if err != nil {
+		panic(err)
+	}
+	return board
+}
+
+// NewBoardFromFEN returns the board described by fen, panicking if it is
+// invalid.
+//
+// Deprecated: Use ParseFEN for input, or MustParseFEN for FENs known to be
+// valid.
+func NewBoardFromFEN(fen string) *Board {
+	return MustParseFEN(fen)
+}
+
+func ParseFEN(fen string) (*Board, error) {
+	board := new(Board)
+	parts := strings.Fields(fen)
+
+	if len(parts) < 4 || len(parts) > 6 {
+		return nil, fmt.Errorf("invalid FEN %q: expected 4 to 6 fields, got %d", fen, len(parts))
+	}
+
+	if err := board.parsePiecePlacement(parts[0]); err != nil {
+		return nil, err
+	}
+
+	switch parts[1] {
+	case "w":
+		board.WhiteToMove = true
+	case "b":
+		board.WhiteToMove = false
+	default:
+		return nil, fmt.Errorf("invalid side to move %q: expected 'w' or 'b'", parts[1])
+	}
+
+	if err := board.parseCastlingRights(parts[2]); err != nil {
+		return nil, err
+	}
+
+	if err := board.parseEnPassantSquare(parts[3]); err != nil {
+		return nil, err
+	}
+
+	board.FullmoveNumber = 1
+	if len(parts) > 4 {
+		halfmoveClock, err := strconv.Atoi(parts[4])
+		if err != nil || halfmoveClock < 0 {
+			return nil, fmt.Errorf("invalid halfmove clock %q", parts[4])
+		}
+		board.HalfmoveClock = halfmoveClock
+	}
+	if len(parts) > 5 {
+		fullmoveNumber, err := strconv.Atoi(parts[5])
+		if err != nil || fullmoveNumber < 1 {
+			return nil, fmt.Errorf("invalid fullmove number %q", parts[5])
+		}
+		board.FullmoveNumber = fullmoveNumber
+	}
+
+	if err := board.validatePosition(); err != nil {
+		return nil, err
+	}
+
+	board.Hash = board.ComputeHash()
+
+	return board, nil
+}
+
+func (b *Board) parsePiecePlacement(placement string) error {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("invalid piece placement %q: expected 8 ranks, got %d", placement, len(ranks))
+	}
+
+	for i, rankString := range ranks {
+		rank := 7 - i
+		file := 0
+
+		for j := 0; j < len(rankString); j++ {
+			c := rankString[j]
+
+			if c >= '1' && c <= '8' {
+				if j > 0 && rankString[j-1] >= '1' && rankString[j-1] <= '8' {
+					return fmt.Errorf("invalid piece placement: rank %d has consecutive digits", rank+1)
+				}
+				file += int(c - '0')
+				continue
+			}
+
+			if file > 7 {
+				return fmt.Errorf("invalid piece placement: rank %d has more than 8 files", rank+1)
+			}
+
+			var pieceType PieceType
+			switch c | 0x20 {
+			case 'p':
+				pieceType = Pawn
+			case 'n':
+				pieceType = Knight
+			case 'b':
+				pieceType = Bishop
+			case 'r':
+				pieceType = Rook
+			case 'q':
+				pieceType = Queen
+			case 'k':
+				pieceType = King
+			default:
+				return fmt.Errorf("invalid piece placement: unknown piece %q", c)
+			}
+
+			color := Black
+			if c >= 'A' && c <= 'Z' {
+				color = White
+			}
+
+			*b.GetBitboard(pieceType, color) |= uint64(1) << (rank*8 + file)
+			file++
+		}
+
+		if file != 8 {
+			return fmt.Errorf("invalid piece placement: rank %d has %d files, expected 8", rank+1, file)
+		}
+	}
+
+	return nil
+}
+
+// parseCastlingRights accepts both X-FEN, where KQkq name the outermost rook
+// on each side of the king, and Shredder-FEN, where the rook's file is given
+// directly. The board switches to Chess960 when the file letters are used or
+// the king and rooks are not on their standard squares.
+func (b *Board) parseCastlingRights(castling string) error {
+	b.CastlingRookFiles = [2][2]int{{7, 0}, {7, 0}}
+	if castling == "-" {
+		return nil
+	}
+
+	for i := 0; i < len(castling); i++ {
+		c := castling[i]
+		color := Black
+		if c >= 'A' && c <= 'Z' {
+			color = White
+		}
+		rank := backRank(color)
+		kingFile := bits.TrailingZeros64(*b.GetBitboard(King, color)) - rank
+		rooks := *b.GetBitboard(Rook, color) >> rank & 0xff
+
+		var side, file int
+		switch c | 0x20 {
+		case 'k':
+			side, file = KingSide, 7
+			for f := 7; f > kingFile; f-- {
+				if rooks&(1<<f) != 0 {
+					file = f
+					break
+				}
+			}
+		case 'q':
+			side, file = QueenSide, 0
+			for f := 0; f < kingFile; f++ {
+				if rooks&(1<<f) != 0 {
+					file = f
+					break
+				}
+			}
+		case 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h':
+			file = int(c|0x20) - 'a'
+			side = QueenSide
+			if file > kingFile {
+				side = KingSide
+			}
+			b.Chess960 = true
+		default:
+			return fmt.Errorf("invalid castling rights %q: unexpected character %q", castling, c)
+		}
+
+		right := b.castlingRight(color, side)
+		if *right {
+			return fmt.Errorf("invalid castling rights %q: duplicate %q", castling, c)
+		}
+		*right = true
+		b.CastlingRookFiles[color][side] = file
+
+		if kingFile != 4 || file != [2]int{7, 0}[side] {
+			b.Chess960 = true
+		}
+	}
+
+	return nil
+}
+
+func (b *Board) parseEnPassantSquare(enPassant string) error {
+	b.EnPassantSquare = -1
+	if enPassant == "-" {
+		return nil
+	}
+
+	square, err := ParseSquare(enPassant)
+	if err != nil {
+		return fmt.Errorf("invalid en passant square: %w", err)
+	}
+	b.EnPassantSquare = int(square)
+
+	return nil
+}
+
+func (b *Board) validatePosition() error {
+	if bits.OnesCount64(b.WhiteKing) != 1 {
+		return fmt.Errorf("invalid position: white must have exactly one king, found %d", bits.OnesCount64(b.WhiteKing))
+	}
+	if bits.OnesCount64(b.BlackKing) != 1 {
+		return fmt.Errorf("invalid position: black must have exactly one king, found %d", bits.OnesCount64(b.BlackKing))
+	}
+
+	const backRanks = uint64(0xff000000000000ff)
+	if (b.WhitePawns|b.BlackPawns)&backRanks != 0 {
+		return fmt.Errorf("invalid position: pawns on the first or eighth rank")
+	}
+
+	for _, color := range [2]Color{White, Black} {
+		for side := KingSide; side <= QueenSide; side++ {
+			if !*b.castlingRight(color, side) {
+				continue
+			}
+			rank := backRank(color)
+			kingFile := bits.TrailingZeros64(*b.GetBitboard(King, color)) - rank
+			rookSquare := b.castlingRookSquare(color, side)
+			sideName, rankName := "kingside", rank/8+1
+			onSide := rookSquare-rank > kingFile
+			if side == QueenSide {
+				sideName, onSide = "queenside", rookSquare-rank < kingFile
+			}
+			if kingFile < 0 || kingFile > 7 || *b.GetBitboard(Rook, color)&(uint64(1)<<rookSquare) == 0 || !onSide {
+				return fmt.Errorf("invalid castling rights: %s cannot castle %s without king on rank %d and rook on %s", color, sideName, rankName, SquareName(uint16(rookSquare)))
+			}
+		}
+	}
+
+	if b.EnPassantSquare >= 0 {
+		epSquare := b.EnPassantSquare
+		var pawnSquare, originSquare int
+		var enemyPawns uint64
+		if b.WhiteToMove {
+			if epSquare/8 != 5 {
+				return fmt.Errorf("invalid en passant square %s: must be on the sixth rank when white is to move", SquareName(uint16(epSquare)))
+			}
+			pawnSquare, originSquare, enemyPawns = epSquare-8, epSquare+8, b.BlackPawns
+		} else {
+			if epSquare/8 != 2 {
+				return fmt.Errorf("invalid en passant square %s: must be on the third rank when black is to move", SquareName(uint16(epSquare)))
+			}
+			pawnSquare, originSquare, enemyPawns = epSquare+8, epSquare-8, b.WhitePawns
+		}
+
+		occupied := b.AllPieces()
+		if enemyPawns&(uint64(1)<<pawnSquare) == 0 || occupied&(uint64(1)<<epSquare|uint64(1)<<originSquare) != 0 {
+			return fmt.Errorf("invalid en passant square %s: no pawn could have just made a double push", SquareName(uint16(epSquare)))
+		}
+	}
+
+	if b.WhiteToMove && IsSquareAttacked(bits.TrailingZeros64(b.BlackKing), White, b) {
+		return fmt.Errorf("invalid position: black is in check while white is to move")
+	}
+	if !b.WhiteToMove && IsSquareAttacked(bits.TrailingZeros64(b.WhiteKing), Black, b) {
+		return fmt.Errorf("invalid position: white is in check while black is to move")
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package chess
+
+import (
+	"fmt"
+	"slices"
+)
+
+type playedMove struct {
+	move Move
+	undo UndoMoveInfo
+}
+
+// Game is a Board together with the moves that led to it, so moves can be
+// taken back and repeated positions recognised.
+type Game struct {
+	start   Board
+	board   Board
+	history []playedMove
+	hashes  []uint64
+	claimed Termination
+}
+
+func NewGame(board *Board) *Game {
+	return &Game{
+		start:  *board,
+		board:  *board,
+		hashes: []uint64{board.Hash},
+	}
+}
+
+func NewGameFromFEN(fen string) (*Game, error) {
+	board, err := ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return NewGame(board), nil
+}
+
+// Board returns the current position. It belongs to the game and must only
+// be changed through Push and Pop.
+func (g *Game) Board() *Board {
+	return &g.board
+}
+
+func (g *Game) StartingPosition() *Board {
+	return g.start.Clone()
+}
+
+// Push plays move if it is legal in the current position and the game is
+// not over, whether by checkmate, an automatic draw or a claimed one.
+func (g *Game) Push(move Move) error {
+	if result := g.Status(); result.IsOver() {
+		return fmt.Errorf("game is over: %s by %s", result, result.Termination)
+	}
+
+	var legal MoveList
+	g.board.GenerateLegalMoves(&legal)
+	if !legal.Contains(move) {
+		return fmt.Errorf("move %s is not legal in %s", move.ToString(), g.board.ToFEN())
+	}
+
+	undo := g.board.MakeMove(move)
+	g.history = append(g.history, playedMove{move: move, undo: undo})
+	g.hashes = append(g.hashes, g.board.Hash)
+	return nil
+}
+
+// Pop takes back the last move and returns it, or reports false when no
+// moves have been played. A draw claim is withdrawn along with the move.
+func (g *Game) Pop() (Move, bool) {
+	if len(g.history) == 0 {
+		return 0, false
+	}
+	g.claimed = Ongoing
+
+	last := g.history[len(g.history)-1]
+	g.board.UndoMove(last.move, last.undo)
+	g.history = g.history[:len(g.history)-1]
+	g.hashes = g.hashes[:len(g.hashes)-1]
+	return last.move, true
+}
+
+func (g *Game) Moves() []Move {
+	moves := make([]Move, len(g.history))
+	for i, played := range g.history {
+		moves[i] = played.move
+	}
+	return moves
+}
+
+func (g *Game) Ply() int {
+	return len(g.history)
+}
+
+// History returns the Zobrist hashes of the positions before the current one,
+// oldest first.
+func (g *Game) History() []uint64 {
+	return slices.Clone(g.hashes[:len(g.hashes)-1])
+}
+
+// PositionAt returns a copy of the position after the first ply moves, from
+// the starting position at 0 to the current one at Ply().
+func (g *Game) PositionAt(ply int) (*Board, error) {
+	if ply < 0 || ply > len(g.history) {
+		return nil, fmt.Errorf("ply %d out of range: game has %d moves", ply, len(g.history))
+	}
+
+	board := g.start.Clone()
+	for _, played := range g.history[:ply] {
+		board.MakeMove(played.move)
+	}
+	return board, nil
+}
+
+// RepetitionCount returns how many times the current position has occurred
+// in the game, counting the current occurrence. Only positions since the last
+// capture or pawn move with the same side to move can repeat it.
+func (g *Game) RepetitionCount() int {
+	current := len(g.hashes) - 1
+	count := 1
+	for i := current - 2; i >= 0 && current-i <= g.board.HalfmoveClock; i -= 2 {
+		if g.hashes[i] == g.hashes[current] {
+			count++
+		}
+	}
+	return count
+}
+
+// Status extends Board.Status with the fivefold repetition rule and any draw
+// claimed with ClaimDraw.
+func (g *Game) Status() GameResult {
+	if g.claimed != Ongoing {
+		return GameResult{Outcome: Draw, Termination: g.claimed}
+	}
+
+	result := g.board.Status()
+	if !result.IsOver() && g.RepetitionCount() >= 5 {
+		return GameResult{Outcome: Draw, Termination: FivefoldRepetition}
+	}
+	return result
+}
+
+// ClaimableDraw reports the draw the player to move may claim in the current
+// position: threefold repetition or the fifty-move rule.
+func (g *Game) ClaimableDraw() (Termination, bool) {
+	if g.Status().IsOver() {
+		return Ongoing, false
+	}
+	if g.RepetitionCount() >= 3 {
+		return ThreefoldRepetition, true
+	}
+	if g.board.HalfmoveClock >= 100 {
+		return FiftyMoveRule, true
+	}
+	return Ongoing, false
+}
+
+// ClaimDraw ends the game as a draw if ClaimableDraw allows it.
+func (g *Game) ClaimDraw() (GameResult, error) {
+	termination, ok := g.ClaimableDraw()
+	if !ok {
+		return g.Status(), fmt.Errorf("no draw can be claimed in %s", g.board.ToFEN())
+	}
+	g.claimed = termination
+	return g.Status(), nil
+}
@@ -0,0 +1,217 @@
+package chess
+
+import "math/bits"
+
+var betweenMasks [64][64]uint64
+
+func initBetweenMasks() {
+	for from := 0; from < 64; from++ {
+		for to := 0; to < 64; to++ {
+			if from == to {
+				continue
+			}
+			fromBit, toBit := uint64(1)<<from, uint64(1)<<to
+			if RookAttacks(from, 0)&toBit != 0 {
+				betweenMasks[from][to] = RookAttacks(from, toBit) & RookAttacks(to, fromBit)
+			} else if BishopAttacks(from, 0)&toBit != 0 {
+				betweenMasks[from][to] = BishopAttacks(from, toBit) & BishopAttacks(to, fromBit)
+			}
+		}
+	}
+}
+
+type sidePieces struct {
+	pawns, knights, bishops, rooks, queens, king uint64
+	all                                          uint64
+}
+
+func (b *Board) sidePieces(color Color) sidePieces {
+	var side sidePieces
+	if color == White {
+		side = sidePieces{b.WhitePawns, b.WhiteKnights, b.WhiteBishops, b.WhiteRooks, b.WhiteQueens, b.WhiteKing, 0}
+	} else {
+		side = sidePieces{b.BlackPawns, b.BlackKnights, b.BlackBishops, b.BlackRooks, b.BlackQueens, b.BlackKing, 0}
+	}
+	side.all = side.pawns | side.knights | side.bishops | side.rooks | side.queens | side.king
+	return side
+}
+
+func pawnAttacks(pawns uint64, color Color) uint64 {
+	if color == White {
+		return (pawns<<9)&NotAFile | (pawns<<7)&NotHFile
+	}
+	return (pawns>>7)&NotAFile | (pawns>>9)&NotHFile
+}
+
+func attackedSquares(side sidePieces, color Color, occupied uint64) uint64 {
+	attacked := pawnAttacks(side.pawns, color)
+	if side.king != 0 {
+		attacked |= KingAttackMasks[bits.TrailingZeros64(side.king)]
+	}
+	for knights := side.knights; knights != 0; knights &= knights - 1 {
+		attacked |= KnightAttackMasks[bits.TrailingZeros64(knights)]
+	}
+	for diagonals := side.bishops | side.queens; diagonals != 0; diagonals &= diagonals - 1 {
+		attacked |= BishopAttacks(bits.TrailingZeros64(diagonals), occupied)
+	}
+	for orthogonals := side.rooks | side.queens; orthogonals != 0; orthogonals &= orthogonals - 1 {
+		attacked |= RookAttacks(bits.TrailingZeros64(orthogonals), occupied)
+	}
+	return attacked
+}
+
+func appendMoves(moves []Move, from int, destinations uint64, enemies uint64) []Move {
+	for ; destinations != 0; destinations &= destinations - 1 {
+		to := bits.TrailingZeros64(destinations)
+		flag := uint16(FlagQuietMove)
+		if enemies&(uint64(1)<<to) != 0 {
+			flag = FlagCapture
+		}
+		moves = append(moves, NewMove(uint16(from), uint16(to), flag))
+	}
+	return moves
+}
+
+func appendPawnMove(moves []Move, from, to int, flag uint16) []Move {
+	if to >= 56 || to <= 7 {
+		return append(moves,
+			NewMove(uint16(from), uint16(to), FlagPromoQueen|flag),
+			NewMove(uint16(from), uint16(to), FlagPromoRook|flag),
+			NewMove(uint16(from), uint16(to), FlagPromoBishop|flag),
+			NewMove(uint16(from), uint16(to), FlagPromoKnight|flag))
+	}
+	return append(moves, NewMove(uint16(from), uint16(to), flag))
+}
+
+// generateLegalMoves appends every legal move to moves without trying any of
+// them on the board. Checkers, pins and the squares the enemy attacks (with
+// our king lifted off the board, so it cannot step back along a checking ray)
+// are worked out first and every destination set is masked with them.
+func (b *Board) generateLegalMoves(moves []Move) []Move {
+	color, enemyColor := Black, White
+	if b.WhiteToMove {
+		color, enemyColor = White, Black
+	}
+	us, them := b.sidePieces(color), b.sidePieces(enemyColor)
+	occupied := us.all | them.all
+	kingSquare := bits.TrailingZeros64(us.king)
+
+	danger := attackedSquares(them, enemyColor, occupied&^us.king)
+
+	checkers := PawnAttackMasks[enemyColor][kingSquare]&them.pawns |
+		KnightAttackMasks[kingSquare]&them.knights |
+		BishopAttacks(kingSquare, occupied)&(them.bishops|them.queens) |
+		RookAttacks(kingSquare, occupied)&(them.rooks|them.queens)
+
+	checkMask := ^uint64(0)
+	switch bits.OnesCount64(checkers) {
+	case 0:
+	case 1:
+		checkMask = checkers | betweenMasks[kingSquare][bits.TrailingZeros64(checkers)]
+	default:
+		return appendMoves(moves, kingSquare, KingAttackMasks[kingSquare]&^us.all&^danger, them.all)
+	}
+
+	var pinned uint64
+	var pinRays [64]uint64
+	snipers := BishopAttacks(kingSquare, them.all)&(them.bishops|them.queens) |
+		RookAttacks(kingSquare, them.all)&(them.rooks|them.queens)
+	for ; snipers != 0; snipers &= snipers - 1 {
+		sniper := bits.TrailingZeros64(snipers)
+		blockers := betweenMasks[kingSquare][sniper] & occupied
+		if bits.OnesCount64(blockers) == 1 && blockers&us.all != 0 {
+			pinned |= blockers
+			pinRays[bits.TrailingZeros64(blockers)] = betweenMasks[kingSquare][sniper] | uint64(1)<<sniper
+		}
+	}
+	allowed := func(from int) uint64 {
+		if pinned&(uint64(1)<<from) != 0 {
+			return checkMask & pinRays[from]
+		}
+		return checkMask
+	}
+
+	for pawns := us.pawns; pawns != 0; pawns &= pawns - 1 {
+		from := bits.TrailingZeros64(pawns)
+		destinations := GeneratePawnMoves(from, color, occupied, them.all) & allowed(from)
+		for ; destinations != 0; destinations &= destinations - 1 {
+			to := bits.TrailingZeros64(destinations)
+			flag := uint16(FlagQuietMove)
+			if them.all&(uint64(1)<<to) != 0 {
+				flag = FlagCapture
+			} else if to-from == 16 || from-to == 16 {
+				flag = FlagDoublePawn
+			}
+			moves = appendPawnMove(moves, from, to, flag)
+		}
+	}
+
+	for knights := us.knights &^ pinned; knights != 0; knights &= knights - 1 {
+		from := bits.TrailingZeros64(knights)
+		moves = appendMoves(moves, from, KnightAttackMasks[from]&^us.all&checkMask, them.all)
+	}
+	for bishops := us.bishops; bishops != 0; bishops &= bishops - 1 {
+		from := bits.TrailingZeros64(bishops)
+		moves = appendMoves(moves, from, BishopAttacks(from, occupied)&^us.all&allowed(from), them.all)
+	}
+	for rooks := us.rooks; rooks != 0; rooks &= rooks - 1 {
+		from := bits.TrailingZeros64(rooks)
+		moves = appendMoves(moves, from, RookAttacks(from, occupied)&^us.all&allowed(from), them.all)
+	}
+	for queens := us.queens; queens != 0; queens &= queens - 1 {
+		from := bits.TrailingZeros64(queens)
+		moves = appendMoves(moves, from, QueenAttacks(from, occupied)&^us.all&allowed(from), them.all)
+	}
+
+	moves = appendMoves(moves, kingSquare, KingAttackMasks[kingSquare]&^us.all&^danger, them.all)
+
+	if checkers == 0 {
+		moves = b.appendLegalCastlingMoves(moves, color, occupied, danger)
+	}
+
+	if b.EnPassantSquare != -1 {
+		moves = b.appendLegalEnPassantMoves(moves, color, us, them, kingSquare, checkers)
+	}
+
+	return moves
+}
+
+func (b *Board) appendLegalCastlingMoves(moves []Move, color Color, occupied, danger uint64) []Move {
+	for side := KingSide; side <= QueenSide; side++ {
+		if b.castlingAllowed(color, side, occupied, danger) {
+			moves = append(moves, b.castlingMove(color, side))
+		}
+	}
+	return moves
+}
+
+// En passant is the one move that removes a piece from a square other than
+// its destination, so a pawn that is not pinned on its own can still expose
+// the king along the rank both pawns leave. The position after the capture
+// is checked directly instead.
+func (b *Board) appendLegalEnPassantMoves(moves []Move, color Color, us, them sidePieces, kingSquare int, checkers uint64) []Move {
+	epSquare := b.EnPassantSquare
+	capturedSquare := epSquare - 8
+	if color == Black {
+		capturedSquare = epSquare + 8
+	}
+	capturedBit := uint64(1) << capturedSquare
+	if them.pawns&capturedBit == 0 {
+		return moves
+	}
+
+	if checkers&^capturedBit&(them.pawns|them.knights) != 0 {
+		return moves
+	}
+
+	for attackers := PawnAttackMasks[color][epSquare] & us.pawns; attackers != 0; attackers &= attackers - 1 {
+		from := bits.TrailingZeros64(attackers)
+		occupied := (us.all|them.all)&^(uint64(1)<<from)&^capturedBit | uint64(1)<<epSquare
+		if BishopAttacks(kingSquare, occupied)&(them.bishops|them.queens) != 0 ||
+			RookAttacks(kingSquare, occupied)&(them.rooks|them.queens) != 0 {
+			continue
+		}
+		moves = append(moves, NewMove(uint16(from), uint16(epSquare), FlagEPCapture))
+	}
+	return moves
+}
@@ -0,0 +1,132 @@
+package chess
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+type magic struct {
+	mask   uint64
+	number uint64
+	shift  uint8
+	offset uint32
+}
+
+var (
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+
+	rookAttackTable   []uint64
+	bishopAttackTable []uint64
+)
+
+// Magic multipliers found by a trial search over sparse random numbers. Each
+// one maps every relevant occupancy of its square to a distinct table slot
+// (or to a slot holding the same attack set).
+var rookMagicNumbers = [64]uint64{
+	0x8080002040008010, 0x0240100020004000, 0x0200200a00108040, 0x0200100822000440,
+	0x06000a0004600810, 0x0980020001140080, 0x040004029008110e, 0x0200004100802402,
+	0x0080800040008020, 0x0050400040201000, 0x0004802001100080, 0x2810801002880180,
+	0x1300800800040080, 0x5802001004020008, 0x0005000402000100, 0x0202000061008a04,
+	0x1010820021004200, 0x500140c010002001, 0x0000410010200101, 0x0900220010084200,
+	0x8001010004100800, 0x0a02008004008002, 0x0400040010020108, 0x01004a0000910044,
+	0x0200400080008030, 0x0000400040201000, 0x0a41004100102000, 0x1040080080100080,
+	0x0008008080040008, 0x1414020080800400, 0x0414888400010210, 0x0008004200008104,
+	0x0242004082002100, 0x2400201000400040, 0x0006200841001100, 0x080240100a002200,
+	0x0124004008080080, 0x8404004100400200, 0xa401000401000200, 0x4020042042001081,
+	0x0030804000208000, 0x3010002002444010, 0x000a002080120040, 0x8808008030028048,
+	0x080c000802808004, 0x0045000204010008, 0x8000020110040008, 0x320002824402002d,
+	0xa800800220c01280, 0x1404400084200480, 0x4021001020004100, 0x0200800800100080,
+	0x498c000800048080, 0x0300020004008080, 0x0004411088020400, 0x4080010084004200,
+	0x04081a8000210143, 0x1600400080110021, 0x0000401008200501, 0x0414081001002005,
+	0x0081000800500205, 0x40070002181c0005, 0x0c04103802010084, 0x2000082400830942,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x0860240108023084, 0x40a0018200910200, 0x0022008102000710, 0x0104040292028416,
+	0x0084042000008000, 0x0000882088800200, 0x009108080cd40880, 0x000a01c20a104280,
+	0x0004040868184088, 0x4420a00114008c80, 0x41000802241020a1, 0x0000080841004224,
+	0x03040d1040104000, 0x8000060824040028, 0x0021024818080880, 0x8240010400820800,
+	0x2184200820081208, 0x201803042820c40a, 0x00040008004c0008, 0x0064211044008000,
+	0x1044002280a04280, 0x8410408280504000, 0x0021001848029000, 0x04004b0904121900,
+	0x40200840101ad840, 0x3108023208108914, 0x0244010d02180101, 0x00c4080014021002,
+	0x0008840040802000, 0x2204084048080210, 0x0000810822280220, 0x000108422b240409,
+	0x0a0848402048c208, 0x0828220850107108, 0x001404c800040124, 0x0808200900200900,
+	0xa7d00a02004c2008, 0x1460004080810098, 0x12c1041428050904, 0x604200a203012a00,
+	0x42041004122184b0, 0x0400523010202410, 0x800080c040441808, 0x0050020122040400,
+	0x1000021040413401, 0x0040300040400080, 0x0044902081000208, 0x3009022281004204,
+	0x8c00880410046004, 0x8692020884050081, 0x0801212108188220, 0x0000000508680060,
+	0x2020041202020000, 0x2020200421220080, 0x0025100292040031, 0x0888010400820400,
+	0x1002808088014120, 0x0800143404041460, 0x0800881020841084, 0x2818024904208810,
+	0x0402000440504108, 0x4221001002100444, 0x200084c808580890, 0x0002100101090200,
+}
+
+func RookAttacks(square int, occupied uint64) uint64 {
+	m := &rookMagics[square]
+	return rookAttackTable[m.offset+uint32(((occupied&m.mask)*m.number)>>m.shift)]
+}
+
+func BishopAttacks(square int, occupied uint64) uint64 {
+	m := &bishopMagics[square]
+	return bishopAttackTable[m.offset+uint32(((occupied&m.mask)*m.number)>>m.shift)]
+}
+
+func QueenAttacks(square int, occupied uint64) uint64 {
+	return RookAttacks(square, occupied) | BishopAttacks(square, occupied)
+}
+
+func initMagics() {
+	rookAttackTable = buildMagicTable(&rookMagics, &rookMagicNumbers, rookRelevantMask, RookRayAttacks)
+	bishopAttackTable = buildMagicTable(&bishopMagics, &bishopMagicNumbers, bishopRelevantMask, BishopRayAttacks)
+}
+
+// The outermost square of each ray never changes the attack set, so it is
+// left out of the mask to keep the tables small.
+func rookRelevantMask(sq int) uint64 {
+	return NorthMasks[sq]&^RankMasks[7] | SouthMasks[sq]&^RankMasks[0] |
+		EastMasks[sq]&^FileMasks[7] | WestMasks[sq]&^FileMasks[0]
+}
+
+func bishopRelevantMask(sq int) uint64 {
+	edges := RankMasks[0] | RankMasks[7] | FileMasks[0] | FileMasks[7]
+	return (NorthEastMasks[sq] | NorthWestMasks[sq] | SouthEastMasks[sq] | SouthWestMasks[sq]) &^ edges
+}
+
+func buildMagicTable(magics *[64]magic, numbers *[64]uint64, relevantMask func(int) uint64, rayAttacks func(int, uint64) uint64) []uint64 {
+	var table []uint64
+
+	for sq := 0; sq < 64; sq++ {
+		mask := relevantMask(sq)
+		relevantBits := bits.OnesCount64(mask)
+
+		m := magic{
+			mask:   mask,
+			number: numbers[sq],
+			shift:  uint8(64 - relevantBits),
+			offset: uint32(len(table)),
+		}
+		entries := make([]uint64, 1<<relevantBits)
+		filled := make([]bool, len(entries))
+
+		subset := uint64(0)
+		for {
+			index := (subset * m.number) >> m.shift
+			attacks := rayAttacks(sq, subset)
+			if filled[index] && entries[index] != attacks {
+				panic(fmt.Sprintf("chess: bad magic number for square %d", sq))
+			}
+			entries[index] = attacks
+			filled[index] = true
+
+			subset = (subset - mask) & mask
+			if subset == 0 {
+				break
+			}
+		}
+
+		magics[sq] = m
+		table = append(table, entries...)
+	}
+
+	return table
+}
@@ -6,6 +6,8 @@ import (
 
 type UndoMoveInfo struct {
 	PreviousEnPassantSquare                                                              int
+	PreviousHalfmoveClock                                                                int
+	PreviousHash                                                                         uint64
 	WhiteKingSideCastle, WhiteQueenSideCastle, BlackKingSideCastle, BlackQueenSideCastle bool
 	CapturedPieceType                                                                    PieceType
 	MovingPieceType                                                                      PieceType
@@ -67,6 +69,27 @@ func (move Move) ToString() string {
 	return fromStr + toStr
 }
 
+func (move Move) PromotionPiece() PieceType {
+	flag := move.Flag()
+	if flag < FlagPromoKnight {
+		return NoPieceType
+	}
+
+	switch flag & 0b1011 {
+	case FlagPromoQueen:
+		return Queen
+	case FlagPromoRook:
+		return Rook
+	case FlagPromoBishop:
+		return Bishop
+	}
+	return Knight
+}
+
+func SquareName(square uint16) string {
+	return fmt.Sprintf("%c%c", 'a'+(square%8), '1'+(square/8))
+}
+
 func ParseSquare(squareStr string) (uint16, error) {
 	if len(squareStr) != 2 {
 		return 0, fmt.Errorf("invalid square format: %s (expected format: e.g., 'e2')", squareStr)
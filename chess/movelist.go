@@ -0,0 +1,48 @@
+package chess
+
+// MaxMoves bounds the number of pseudo-legal moves in any reachable position
+// (the known maximum for legal moves is 218).
+const MaxMoves = 256
+
+type MoveList struct {
+	moves [MaxMoves]Move
+	count int
+}
+
+func (l *MoveList) Len() int {
+	return l.count
+}
+
+func (l *MoveList) At(i int) Move {
+	return l.moves[i]
+}
+
+func (l *MoveList) Add(move Move) {
+	l.moves[l.count] = move
+	l.count++
+}
+
+func (l *MoveList) Clear() {
+	l.count = 0
+}
+
+func (l *MoveList) Moves() []Move {
+	return l.moves[:l.count]
+}
+
+func (l *MoveList) Contains(move Move) bool {
+	for _, m := range l.moves[:l.count] {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Board) GenerateLegalMoves(list *MoveList) {
+	list.count = len(b.generateLegalMoves(list.moves[:0]))
+}
+
+func (b *Board) GeneratePseudoLegalMoves(list *MoveList) {
+	list.count = len(appendAllPossibleMoves(list.moves[:0], b))
+}
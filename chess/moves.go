@@ -16,6 +16,9 @@ var (
 	PawnAttackMasks   [2][64]uint64
 	KnightAttackMasks [64]uint64
 	KingAttackMasks   [64]uint64
+
+	FileMasks [8]uint64
+	RankMasks [8]uint64
 )
 
 const NotAFile uint64 = 0xfefefefefefefefe
@@ -25,6 +28,10 @@ const Rank6Mask uint64 = 0x00ff000000000000
 const NotABFile = 0xfcfcfcfcfcfcfcfc
 const NotGHFile = 0x3f3f3f3f3f3f3f3f
 
+// a1 is bit 0 and dark, so the first rank byte of LightSquares is 0xaa.
+const LightSquares uint64 = 0x55aa55aa55aa55aa
+const DarkSquares uint64 = 0xaa55aa55aa55aa55
+
 func init() {
 	for sq := 0; sq < 64; sq++ {
 		NorthMasks[sq] = generateNorthMask(sq)
@@ -41,7 +48,13 @@ func init() {
 		PawnAttackMasks[Black][sq] = generatePawnAttackMask(Black, sq)
 		KnightAttackMasks[sq] = generateKnightAttackMask(sq)
 		KingAttackMasks[sq] = generateKingAttackMask(sq)
+
+		FileMasks[sq%8] |= uint64(1) << sq
+		RankMasks[sq/8] |= uint64(1) << sq
 	}
+
+	initMagics()
+	initBetweenMasks()
 }
 
 func generateNorthMask(sq int) uint64 {
@@ -193,6 +206,10 @@ func generateKingAttackMask(sq int) uint64 {
 	return attacks
 }
 func GenerateRookMoves(rookSquare int, allOccupiedSquares uint64, friendlyPieces uint64) uint64 {
+	return RookAttacks(rookSquare, allOccupiedSquares) &^ friendlyPieces
+}
+
+func RookRayAttacks(rookSquare int, allOccupiedSquares uint64) uint64 {
 	var movesNorth, movesEast, movesSouth, movesWest uint64
 
 	blockersNorth := allOccupiedSquares & NorthMasks[rookSquare]
@@ -227,15 +244,14 @@ func GenerateRookMoves(rookSquare int, allOccupiedSquares uint64, friendlyPieces
 		movesEast = EastMasks[rookSquare]
 	}
 
-	allPossibleMoves := movesNorth | movesEast | movesSouth | movesWest
-
-	legalMoves := allPossibleMoves &^ friendlyPieces
-
-	return legalMoves
+	return movesNorth | movesEast | movesSouth | movesWest
 }
 
 func GenerateRookMovesDetailed(board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
-	moves := make([]Move, 0, 256)
+	return appendRookMoves(make([]Move, 0, 256), board, color, allPieces, friendlyPieces)
+}
+
+func appendRookMoves(moves []Move, board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
 	var rookBitboard uint64
 	var enemyColor Color
 
@@ -283,6 +299,10 @@ func GenerateRookMovesDetailed(board *Board, color Color, allPieces uint64, frie
 }
 
 func GenerateBishopMoves(bishopSquare int, allOccupiedSquares uint64, friendlyPieces uint64) uint64 {
+	return BishopAttacks(bishopSquare, allOccupiedSquares) &^ friendlyPieces
+}
+
+func BishopRayAttacks(bishopSquare int, allOccupiedSquares uint64) uint64 {
 	var movesNorthEast, movesNorthWest, movesSouthEast, movesSouthWest uint64
 
 	blockersNorthEast := allOccupiedSquares & NorthEastMasks[bishopSquare]
@@ -317,13 +337,14 @@ func GenerateBishopMoves(bishopSquare int, allOccupiedSquares uint64, friendlyPi
 		movesSouthWest = SouthWestMasks[bishopSquare]
 	}
 
-	allPossibleMoves := movesNorthEast | movesNorthWest | movesSouthEast | movesSouthWest
-
-	return allPossibleMoves &^ friendlyPieces
+	return movesNorthEast | movesNorthWest | movesSouthEast | movesSouthWest
 }
 
 func GenerateBishopMovesDetailed(board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
-	moves := make([]Move, 0, 256)
+	return appendBishopMoves(make([]Move, 0, 256), board, color, allPieces, friendlyPieces)
+}
+
+func appendBishopMoves(moves []Move, board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
 	var bishopBitboard uint64
 
 	if color == White {
@@ -371,13 +392,14 @@ func GenerateBishopMovesDetailed(board *Board, color Color, allPieces uint64, fr
 }
 
 func GenerateQueenMoves(queenSquare int, allOccupiedSquares uint64, friendlyPieces uint64) uint64 {
-	rookMoves := GenerateRookMoves(queenSquare, allOccupiedSquares, friendlyPieces)
-	bishopMoves := GenerateBishopMoves(queenSquare, allOccupiedSquares, friendlyPieces)
-	return rookMoves | bishopMoves
+	return QueenAttacks(queenSquare, allOccupiedSquares) &^ friendlyPieces
 }
 
 func GenerateQueenMovesDetailed(board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
-	moves := make([]Move, 0, 256)
+	return appendQueenMoves(make([]Move, 0, 256), board, color, allPieces, friendlyPieces)
+}
+
+func appendQueenMoves(moves []Move, board *Board, color Color, allPieces uint64, friendlyPieces uint64) []Move {
 	var queenBitboard uint64
 
 	if color == White {
@@ -467,7 +489,10 @@ func GeneratePawnMoves(pawnSquare int, color Color, allOccupiedSquares uint64, e
 	return moves
 }
 func GeneratePawnMovesDetailed(board *Board, color Color) []Move {
-	moves := make([]Move, 0, 40)
+	return appendPawnMoves(make([]Move, 0, 40), board, color)
+}
+
+func appendPawnMoves(moves []Move, board *Board, color Color) []Move {
 	var pawnBitboard uint64
 	var enemyPieces uint64
 
@@ -535,7 +560,10 @@ func GenerateKingMoves(kingSquare int, allOccupiedSquares uint64, friendlyPieces
 }
 
 func GenerateKingMovesDetailed(board *Board, color Color, friendlyPieces uint64) []Move {
-	moves := make([]Move, 0, 256)
+	return appendKingMoves(make([]Move, 0, 256), board, color, friendlyPieces)
+}
+
+func appendKingMoves(moves []Move, board *Board, color Color, friendlyPieces uint64) []Move {
 	var kingBitboard uint64
 
 	if color == White {
@@ -584,7 +612,10 @@ func GenerateKnightMoves(knightSquare int, friendlyPieces uint64) uint64 {
 }
 
 func GenerateKnightMovesDetailed(board *Board, color Color, friendlyPieces uint64) []Move {
-	moves := make([]Move, 0, 256)
+	return appendKnightMoves(make([]Move, 0, 256), board, color, friendlyPieces)
+}
+
+func appendKnightMoves(moves []Move, board *Board, color Color, friendlyPieces uint64) []Move {
 	var knightBitboard uint64
 
 	if color == White {
@@ -662,240 +693,62 @@ func IsSquareAttacked(square int, attackerColor Color, board *Board) bool {
 
 	allPieces := board.AllPieces()
 
-	orthogonalAttackers := attackingRooks | attackingQueens
-	if orthogonalAttackers != 0 {
-
-		blockersNorth := allPieces & NorthMasks[square]
-		if blockersNorth != 0 {
-			firstBlockerIndex := bits.TrailingZeros64(blockersNorth)
-			if ((uint64(1) << firstBlockerIndex) & orthogonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (NorthMasks[square] & orthogonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersSouth := allPieces & SouthMasks[square]
-		if blockersSouth != 0 {
-			firstBlockerIndex := 63 - bits.LeadingZeros64(blockersSouth)
-			if ((uint64(1) << firstBlockerIndex) & orthogonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (SouthMasks[square] & orthogonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersEast := allPieces & EastMasks[square]
-		if blockersEast != 0 {
-			firstBlockerIndex := bits.TrailingZeros64(blockersEast)
-			if ((uint64(1) << firstBlockerIndex) & orthogonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (EastMasks[square] & orthogonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersWest := allPieces & WestMasks[square]
-		if blockersWest != 0 {
-			firstBlockerIndex := 63 - bits.LeadingZeros64(blockersWest)
-			if ((uint64(1) << firstBlockerIndex) & orthogonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (WestMasks[square] & orthogonalAttackers) != 0 {
-				return true
-			}
-		}
+	if RookAttacks(square, allPieces)&(attackingRooks|attackingQueens) != 0 {
+		return true
 	}
-
-	diagonalAttackers := attackingBishops | attackingQueens
-	if diagonalAttackers != 0 {
-
-		blockersNorthEast := allPieces & NorthEastMasks[square]
-		if blockersNorthEast != 0 {
-			firstBlockerIndex := bits.TrailingZeros64(blockersNorthEast)
-			if ((uint64(1) << firstBlockerIndex) & diagonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (NorthEastMasks[square] & diagonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersNorthWest := allPieces & NorthWestMasks[square]
-		if blockersNorthWest != 0 {
-			firstBlockerIndex := bits.TrailingZeros64(blockersNorthWest)
-			if ((uint64(1) << firstBlockerIndex) & diagonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (NorthWestMasks[square] & diagonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersSouthEast := allPieces & SouthEastMasks[square]
-		if blockersSouthEast != 0 {
-			firstBlockerIndex := 63 - bits.LeadingZeros64(blockersSouthEast)
-			if ((uint64(1) << firstBlockerIndex) & diagonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (SouthEastMasks[square] & diagonalAttackers) != 0 {
-				return true
-			}
-		}
-
-		blockersSouthWest := allPieces & SouthWestMasks[square]
-		if blockersSouthWest != 0 {
-			firstBlockerIndex := 63 - bits.LeadingZeros64(blockersSouthWest)
-			if ((uint64(1) << firstBlockerIndex) & diagonalAttackers) != 0 {
-				return true
-			}
-		} else {
-			if (SouthWestMasks[square] & diagonalAttackers) != 0 {
-				return true
-			}
-		}
+	if BishopAttacks(square, allPieces)&(attackingBishops|attackingQueens) != 0 {
+		return true
 	}
 
 	return false
 }
 
-func CanCastleKingSide(board *Board) bool {
-	if board.WhiteToMove && !board.WhiteKingSideCastle {
-		return false
-	} else if !board.WhiteToMove && !board.BlackKingSideCastle {
-		return false
-	}
-
-	var squaresBetween [2]int
-	var kingPath [3]int
-	if board.WhiteToMove {
-		squaresBetween[0] = F1
-		squaresBetween[1] = G1
-		kingPath[0] = E1
-		kingPath[1] = F1
-		kingPath[2] = G1
-	} else {
-		squaresBetween[0] = F8
-		squaresBetween[1] = G8
-		kingPath[0] = E8
-		kingPath[1] = F8
-		kingPath[2] = G8
-	}
-
-	for _, square := range squaresBetween {
-		_, _, populated := board.PieceAt(square)
-
-		if populated {
-			return false
-		}
-	}
-
-	var enemyColor Color
-	if board.WhiteToMove {
-		enemyColor = Black
-	} else {
-		enemyColor = White
-	}
-
-	for _, square := range kingPath {
-		if IsSquareAttacked(square, enemyColor, board) {
-			return false
-		}
+func (b *Board) InCheck() bool {
+	if b.WhiteToMove {
+		return IsSquareAttacked(bits.TrailingZeros64(b.WhiteKing), Black, b)
 	}
+	return IsSquareAttacked(bits.TrailingZeros64(b.BlackKing), White, b)
+}
 
-	return true
+func CanCastleKingSide(board *Board) bool {
+	return canCastle(board, KingSide)
 }
 
 func CanCastleQueenSide(board *Board) bool {
-	if board.WhiteToMove && !board.WhiteQueenSideCastle {
-		return false
-	} else if !board.WhiteToMove && !board.BlackQueenSideCastle {
-		return false
-	}
-
-	var squaresBetween [3]int
-	var kingPath [3]int
-	if board.WhiteToMove {
-		squaresBetween[0] = D1
-		squaresBetween[1] = C1
-		squaresBetween[2] = B1
-		kingPath[0] = E1
-		kingPath[1] = D1
-		kingPath[2] = C1
-	} else {
-		squaresBetween[0] = D8
-		squaresBetween[1] = C8
-		squaresBetween[2] = B8
-		kingPath[0] = E8
-		kingPath[1] = D8
-		kingPath[2] = C8
-	}
-
-	for _, square := range squaresBetween {
-		_, _, populated := board.PieceAt(square)
-
-		if populated {
-			return false
-		}
-	}
+	return canCastle(board, QueenSide)
+}
 
-	var enemyColor Color
+func canCastle(board *Board, side int) bool {
+	color := Black
 	if board.WhiteToMove {
-		enemyColor = Black
-	} else {
-		enemyColor = White
+		color = White
 	}
-
-	for _, square := range kingPath {
-		if IsSquareAttacked(square, enemyColor, board) {
-			return false
-		}
+	if !*board.castlingRight(color, side) {
+		return false
 	}
-
-	return true
+	occupied, danger := board.castlingDanger(color)
+	return board.castlingAllowed(color, side, occupied, danger)
 }
 
 func GenerateCastlingMoves(board *Board, color Color) []Move {
-	moves := make([]Move, 0, 2)
-
-	if color == White {
-		if CanCastleKingSide(board) {
-			move := NewMove(E1, G1, FlagKingCastle)
-			moves = append(moves, move)
-		}
-
-		if CanCastleQueenSide(board) {
-			move := NewMove(E1, C1, FlagQueenCastle)
-			moves = append(moves, move)
-		}
-	} else {
-		if CanCastleKingSide(board) {
-			move := NewMove(E8, G8, FlagKingCastle)
-			moves = append(moves, move)
-		}
+	return appendCastlingMoves(make([]Move, 0, 2), board, color)
+}
 
-		if CanCastleQueenSide(board) {
-			move := NewMove(E8, C8, FlagQueenCastle)
-			moves = append(moves, move)
-		}
+func appendCastlingMoves(moves []Move, board *Board, color Color) []Move {
+	if CanCastleKingSide(board) {
+		moves = append(moves, board.castlingMove(color, KingSide))
+	}
+	if CanCastleQueenSide(board) {
+		moves = append(moves, board.castlingMove(color, QueenSide))
 	}
-
 	return moves
 }
 
 func GenerateEnPassantMoves(board *Board) []Move {
-	moves := make([]Move, 0, 2)
+	return appendEnPassantMoves(make([]Move, 0, 2), board)
+}
 
+func appendEnPassantMoves(moves []Move, board *Board) []Move {
 	if board.EnPassantSquare == -1 {
 		return moves
 	}
@@ -954,45 +807,14 @@ func GenerateEnPassantMoves(board *Board) []Move {
 	return moves
 }
 func GenerateAllLegalMoves(board *Board) []Move {
-	legalMoves := make([]Move, 0, 256)
-	allPossibleMoves := GenerateAllPossibleMoves(board)
-
-	var currentColor Color
-
-	if board.WhiteToMove {
-		currentColor = White
-	} else {
-		currentColor = Black
-	}
-
-	for _, move := range allPossibleMoves {
-		undoInfo := board.MakeMove(move)
-
-		var currentKingBitboard uint64
-		var enemyColor Color
-
-		if currentColor == White {
-			currentKingBitboard = board.WhiteKing
-			enemyColor = Black
-		} else {
-			currentKingBitboard = board.BlackKing
-			enemyColor = White
-		}
-
-		kingSquare := bits.TrailingZeros64(currentKingBitboard)
-
-		if !IsSquareAttacked(kingSquare, enemyColor, board) {
-			legalMoves = append(legalMoves, move)
-		}
-
-		board.UndoMove(move, undoInfo)
-	}
-
-	return legalMoves
+	return board.generateLegalMoves(make([]Move, 0, 256))
 }
 
 func GenerateAllPossibleMoves(board *Board) []Move {
-	moves := make([]Move, 0, 256)
+	return appendAllPossibleMoves(make([]Move, 0, 256), board)
+}
+
+func appendAllPossibleMoves(moves []Move, board *Board) []Move {
 	var color Color
 	var friendlyPieces uint64
 
@@ -1006,14 +828,14 @@ func GenerateAllPossibleMoves(board *Board) []Move {
 
 	allPieces := board.AllPieces()
 
-	moves = append(moves, GeneratePawnMovesDetailed(board, color)...)
-	moves = append(moves, GenerateKnightMovesDetailed(board, color, friendlyPieces)...)
-	moves = append(moves, GenerateBishopMovesDetailed(board, color, allPieces, friendlyPieces)...)
-	moves = append(moves, GenerateRookMovesDetailed(board, color, allPieces, friendlyPieces)...)
-	moves = append(moves, GenerateQueenMovesDetailed(board, color, allPieces, friendlyPieces)...)
-	moves = append(moves, GenerateKingMovesDetailed(board, color, friendlyPieces)...)
-	moves = append(moves, GenerateCastlingMoves(board, color)...)
-	moves = append(moves, GenerateEnPassantMoves(board)...)
+	moves = appendPawnMoves(moves, board, color)
+	moves = appendKnightMoves(moves, board, color, friendlyPieces)
+	moves = appendBishopMoves(moves, board, color, allPieces, friendlyPieces)
+	moves = appendRookMoves(moves, board, color, allPieces, friendlyPieces)
+	moves = appendQueenMoves(moves, board, color, allPieces, friendlyPieces)
+	moves = appendKingMoves(moves, board, color, friendlyPieces)
+	moves = appendCastlingMoves(moves, board, color)
+	moves = appendEnPassantMoves(moves, board)
 
 	return moves
 }
@@ -0,0 +1,97 @@
+package chess
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type DivideEntry struct {
+	Move  Move
+	Nodes uint64
+}
+
+type DivideResult struct {
+	Entries []DivideEntry
+	Nodes   uint64
+}
+
+func Perft(board *Board, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	var list MoveList
+	board.GenerateLegalMoves(&list)
+	if depth == 1 {
+		return uint64(list.Len())
+	}
+
+	var nodes uint64
+	for _, move := range list.Moves() {
+		undoInfo := board.MakeMove(move)
+		nodes += Perft(board, depth-1)
+		board.UndoMove(move, undoInfo)
+	}
+	return nodes
+}
+
+func Divide(board *Board, depth int) DivideResult {
+	return DivideParallel(board, depth, 1)
+}
+
+func PerftParallel(board *Board, depth, workers int) uint64 {
+	return DivideParallel(board, depth, workers).Nodes
+}
+
+// DivideParallel splits the root moves across workers goroutines, each
+// searching its own copy of the board. A non-positive workers uses
+// GOMAXPROCS.
+func DivideParallel(board *Board, depth, workers int) DivideResult {
+	if depth <= 0 {
+		return DivideResult{Nodes: 1}
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var list MoveList
+	board.GenerateLegalMoves(&list)
+
+	result := DivideResult{Entries: make([]DivideEntry, list.Len())}
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				clone := board.Clone()
+				move := list.At(i)
+				clone.MakeMove(move)
+				result.Entries[i] = DivideEntry{Move: move, Nodes: Perft(clone, depth-1)}
+			}
+		}()
+	}
+	for i := 0; i < list.Len(); i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, entry := range result.Entries {
+		result.Nodes += entry.Nodes
+	}
+	return result
+}
+
+func (r DivideResult) String() string {
+	var out strings.Builder
+	for _, entry := range r.Entries {
+		fmt.Fprintf(&out, "%s: %d\n", entry.Move.ToString(), entry.Nodes)
+	}
+	fmt.Fprintf(&out, "\nNodes searched: %d\n", r.Nodes)
+	return out.String()
+}
@@ -0,0 +1,217 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+var sanPieceLetters = [...]byte{
+	Rook:   'R',
+	Knight: 'N',
+	Bishop: 'B',
+	Queen:  'Q',
+	King:   'K',
+}
+
+func sanPieceType(letter byte) (PieceType, bool) {
+	switch letter {
+	case 'R':
+		return Rook, true
+	case 'N':
+		return Knight, true
+	case 'B':
+		return Bishop, true
+	case 'Q':
+		return Queen, true
+	case 'K':
+		return King, true
+	}
+	return NoPieceType, false
+}
+
+func (b *Board) MoveToSAN(move Move) string {
+	var san strings.Builder
+
+	from := move.From()
+	to := move.To()
+	flag := move.Flag()
+
+	switch flag {
+	case FlagKingCastle:
+		san.WriteString("O-O")
+	case FlagQueenCastle:
+		san.WriteString("O-O-O")
+	default:
+		pieceType, _, _ := b.PieceAt(int(from))
+		isCapture := flag&FlagCapture != 0
+
+		if pieceType == Pawn {
+			if isCapture {
+				san.WriteByte(byte('a' + from%8))
+			}
+		} else {
+			san.WriteByte(sanPieceLetters[pieceType])
+			san.WriteString(b.sanDisambiguation(move, pieceType))
+		}
+
+		if isCapture {
+			san.WriteByte('x')
+		}
+		san.WriteString(SquareName(to))
+
+		if promoPiece := move.PromotionPiece(); promoPiece != NoPieceType {
+			san.WriteByte('=')
+			san.WriteByte(sanPieceLetters[promoPiece])
+		}
+	}
+
+	undoInfo := b.MakeMove(move)
+	if b.InCheck() {
+		if len(GenerateAllLegalMoves(b)) == 0 {
+			san.WriteByte('#')
+		} else {
+			san.WriteByte('+')
+		}
+	}
+	b.UndoMove(move, undoInfo)
+
+	return san.String()
+}
+
+func (b *Board) sanDisambiguation(move Move, pieceType PieceType) string {
+	from := move.From()
+	ambiguous, sameFile, sameRank := false, false, false
+
+	for _, other := range GenerateAllLegalMoves(b) {
+		otherFrom := other.From()
+		if otherFrom == from || other.To() != move.To() {
+			continue
+		}
+		if otherType, _, _ := b.PieceAt(int(otherFrom)); otherType != pieceType {
+			continue
+		}
+
+		ambiguous = true
+		if otherFrom%8 == from%8 {
+			sameFile = true
+		}
+		if otherFrom/8 == from/8 {
+			sameRank = true
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(rune('a' + from%8))
+	case !sameRank:
+		return string(rune('1' + from/8))
+	}
+	return SquareName(from)
+}
+
+// ParseSAN parses a move in SAN, ignoring check and annotation suffixes and
+// the optional "e.p." after an en passant capture.
+func (b *Board) ParseSAN(san string) (Move, error) {
+	notation := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+	enPassant := strings.HasSuffix(notation, "e.p.")
+	if enPassant {
+		notation = strings.TrimRight(strings.TrimSpace(strings.TrimSuffix(notation, "e.p.")), "+#!?")
+	}
+	if notation == "" {
+		return 0, fmt.Errorf("empty SAN move")
+	}
+
+	legalMoves := GenerateAllLegalMoves(b)
+
+	switch notation {
+	case "O-O", "0-0":
+		for _, move := range legalMoves {
+			if move.Flag() == FlagKingCastle {
+				return move, nil
+			}
+		}
+		return 0, fmt.Errorf("castling move %s is not legal", san)
+	case "O-O-O", "0-0-0":
+		for _, move := range legalMoves {
+			if move.Flag() == FlagQueenCastle {
+				return move, nil
+			}
+		}
+		return 0, fmt.Errorf("castling move %s is not legal", san)
+	}
+
+	promoPiece := NoPieceType
+	if last := notation[len(notation)-1]; last < '1' || last > '8' {
+		pieceType, ok := sanPieceType(last)
+		if !ok || pieceType == King {
+			return 0, fmt.Errorf("invalid promotion piece in SAN move: %s", san)
+		}
+		promoPiece = pieceType
+		notation = strings.TrimSuffix(notation[:len(notation)-1], "=")
+	}
+
+	if len(notation) < 2 {
+		return 0, fmt.Errorf("invalid SAN move: %s", san)
+	}
+	toSquare, err := ParseSquare(notation[len(notation)-2:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid destination in SAN move %s: %w", san, err)
+	}
+	notation = notation[:len(notation)-2]
+
+	pieceType := Pawn
+	if len(notation) > 0 {
+		if parsed, ok := sanPieceType(notation[0]); ok {
+			pieceType = parsed
+			notation = notation[1:]
+		}
+	}
+	notation = strings.TrimSuffix(notation, "x")
+
+	fromFile, fromRank := -1, -1
+	for i := 0; i < len(notation); i++ {
+		switch c := notation[i]; {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = int(c - '1')
+		default:
+			return 0, fmt.Errorf("invalid SAN move: %s", san)
+		}
+	}
+
+	var match Move
+	matches := 0
+	for _, move := range legalMoves {
+		from := move.From()
+		if move.To() != toSquare || move.PromotionPiece() != promoPiece {
+			continue
+		}
+		if move.Flag() == FlagKingCastle || move.Flag() == FlagQueenCastle {
+			continue
+		}
+		if movingPiece, _, _ := b.PieceAt(int(from)); movingPiece != pieceType {
+			continue
+		}
+		if fromFile >= 0 && int(from%8) != fromFile {
+			continue
+		}
+		if fromRank >= 0 && int(from/8) != fromRank {
+			continue
+		}
+		match = move
+		matches++
+	}
+
+	switch {
+	case matches == 0:
+		return 0, fmt.Errorf("move %s is not legal", san)
+	case matches == 1 && enPassant && match.Flag() != FlagEPCapture:
+		return 0, fmt.Errorf("move %s is not an en passant capture", san)
+	case matches == 1:
+		return match, nil
+	}
+	return 0, fmt.Errorf("move %s is ambiguous", san)
+}
@@ -0,0 +1,133 @@
+package chess
+
+import "math/bits"
+
+// seeValues are the piece values SEE counts in, in centipawns. The king is
+// worth more than everything else together so it is never traded.
+var seeValues = [...]int{
+	Pawn:        100,
+	Knight:      320,
+	Bishop:      330,
+	Rook:        500,
+	Queen:       900,
+	King:        20000,
+	NoPieceType: 0,
+}
+
+// SEE returns the material the side to move wins by playing move and then
+// letting both sides recapture on its destination square with their least
+// valuable attacker for as long as that gains material. Sliders uncovered by
+// earlier captures join in. Pins and checks are ignored, so the result is an
+// estimate. Quiet moves score zero unless the piece can be taken.
+func SEE(board *Board, move Move) int {
+	flag := move.Flag()
+	if flag == FlagKingCastle || flag == FlagQueenCastle {
+		return 0
+	}
+
+	from, to := int(move.From()), int(move.To())
+	attacker, color, ok := board.PieceAt(from)
+	if !ok {
+		return 0
+	}
+
+	occupied := board.AllPieces() &^ (uint64(1) << from)
+	var gain [32]int
+	if flag == FlagEPCapture {
+		gain[0] = seeValues[Pawn]
+		if color == White {
+			occupied &^= uint64(1) << (to - 8)
+		} else {
+			occupied &^= uint64(1) << (to + 8)
+		}
+	} else if flag&FlagCapture != 0 {
+		victim, _, _ := board.PieceAt(to)
+		gain[0] = seeValues[victim]
+	}
+
+	onSquare := attacker
+	if promotion := move.PromotionPiece(); promotion != NoPieceType {
+		gain[0] += seeValues[promotion] - seeValues[Pawn]
+		onSquare = promotion
+	}
+
+	diagonals := board.WhiteBishops | board.BlackBishops | board.WhiteQueens | board.BlackQueens
+	orthogonals := board.WhiteRooks | board.BlackRooks | board.WhiteQueens | board.BlackQueens
+	attackers := board.attackersTo(to, occupied) & occupied
+	promotes := to < 8 || to >= 56
+
+	side := White
+	if color == White {
+		side = Black
+	}
+
+	depth := 0
+	for {
+		us := board.sidePieces(side)
+		piece, square := leastValuableAttacker(attackers, us)
+		if piece == NoPieceType {
+			break
+		}
+		if piece == King && attackers&^us.all != 0 {
+			break
+		}
+
+		depth++
+		gain[depth] = seeValues[onSquare] - gain[depth-1]
+		onSquare = piece
+		if piece == Pawn && promotes {
+			gain[depth] += seeValues[Queen] - seeValues[Pawn]
+			onSquare = Queen
+		}
+
+		occupied &^= uint64(1) << square
+		attackers &^= uint64(1) << square
+		if piece == Pawn || piece == Bishop || piece == Queen {
+			attackers |= BishopAttacks(to, occupied) & diagonals & occupied
+		}
+		if piece == Rook || piece == Queen {
+			attackers |= RookAttacks(to, occupied) & orthogonals & occupied
+		}
+
+		if side == White {
+			side = Black
+		} else {
+			side = White
+		}
+	}
+
+	for ; depth > 0; depth-- {
+		gain[depth-1] = -max(-gain[depth-1], gain[depth])
+	}
+	return gain[0]
+}
+
+// attackersTo returns the pieces of both colours attacking square, with
+// sliders seen through the given occupancy.
+func (b *Board) attackersTo(square int, occupied uint64) uint64 {
+	return PawnAttackMasks[White][square]&b.WhitePawns |
+		PawnAttackMasks[Black][square]&b.BlackPawns |
+		KnightAttackMasks[square]&(b.WhiteKnights|b.BlackKnights) |
+		KingAttackMasks[square]&(b.WhiteKing|b.BlackKing) |
+		BishopAttacks(square, occupied)&(b.WhiteBishops|b.BlackBishops|b.WhiteQueens|b.BlackQueens) |
+		RookAttacks(square, occupied)&(b.WhiteRooks|b.BlackRooks|b.WhiteQueens|b.BlackQueens)
+}
+
+func leastValuableAttacker(attackers uint64, side sidePieces) (PieceType, int) {
+	for _, candidate := range [...]struct {
+		piece  PieceType
+		pieces uint64
+	}{
+		{Pawn, side.pawns},
+		{Knight, side.knights},
+		{Bishop, side.bishops},
+		{Rook, side.rooks},
+		{Queen, side.queens},
+		{King, side.king},
+	} {
+		if found := attackers & candidate.pieces; found != 0 {
+			return candidate.piece, bits.TrailingZeros64(found)
+		}
+	}
+	return NoPieceType, 0
+}
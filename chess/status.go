@@ -0,0 +1,133 @@
+package chess
+
+import "math/bits"
+
+type Outcome int
+type Termination int
+
+const (
+	NoOutcome Outcome = iota
+	WhiteWins
+	BlackWins
+	Draw
+)
+
+const (
+	Ongoing Termination = iota
+	Checkmate
+	Stalemate
+	InsufficientMaterial
+	FiftyMoveRule
+	SeventyFiveMoveRule
+	ThreefoldRepetition
+	FivefoldRepetition
+)
+
+type GameResult struct {
+	Outcome     Outcome
+	Termination Termination
+}
+
+func (o Outcome) String() string {
+	switch o {
+	case WhiteWins:
+		return "1-0"
+	case BlackWins:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+func (t Termination) String() string {
+	switch t {
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case FiftyMoveRule:
+		return "fifty-move rule"
+	case SeventyFiveMoveRule:
+		return "seventy-five-move rule"
+	case ThreefoldRepetition:
+		return "threefold repetition"
+	case FivefoldRepetition:
+		return "fivefold repetition"
+	}
+	return "ongoing"
+}
+
+func (r GameResult) IsOver() bool {
+	return r.Outcome != NoOutcome
+}
+
+func (r GameResult) Winner() (Color, bool) {
+	switch r.Outcome {
+	case WhiteWins:
+		return White, true
+	case BlackWins:
+		return Black, true
+	}
+	return 0, false
+}
+
+func (r GameResult) String() string {
+	return r.Outcome.String()
+}
+
+// Status reports checkmate, stalemate and the draws that end the game
+// without a claim: dead positions and the seventy-five-move rule. Draws that
+// must be claimed, and repetitions, which need the move history, are left to
+// Game.
+func (b *Board) Status() GameResult {
+	legalMoves := GenerateAllLegalMoves(b)
+
+	if len(legalMoves) == 0 {
+		if !b.InCheck() {
+			return GameResult{Outcome: Draw, Termination: Stalemate}
+		}
+		if b.WhiteToMove {
+			return GameResult{Outcome: BlackWins, Termination: Checkmate}
+		}
+		return GameResult{Outcome: WhiteWins, Termination: Checkmate}
+	}
+
+	if b.IsInsufficientMaterial() {
+		return GameResult{Outcome: Draw, Termination: InsufficientMaterial}
+	}
+
+	if b.HalfmoveClock >= 150 {
+		return GameResult{Outcome: Draw, Termination: SeventyFiveMoveRule}
+	}
+
+	return GameResult{Outcome: NoOutcome, Termination: Ongoing}
+}
+
+func (b *Board) IsInsufficientMaterial() bool {
+	if b.WhitePawns|b.BlackPawns|b.WhiteRooks|b.BlackRooks|b.WhiteQueens|b.BlackQueens != 0 {
+		return false
+	}
+
+	knights := b.WhiteKnights | b.BlackKnights
+	bishops := b.WhiteBishops | b.BlackBishops
+
+	if knights == 0 && bishops == 0 {
+		return true
+	}
+
+	if bishops == 0 && bits.OnesCount64(knights) == 1 {
+		return true
+	}
+
+	if knights == 0 {
+		if bits.OnesCount64(bishops) == 1 {
+			return true
+		}
+		return bishops&LightSquares == 0 || bishops&DarkSquares == 0
+	}
+
+	return false
+}
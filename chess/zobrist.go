@@ -0,0 +1,109 @@
+package chess
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const debugHash = false
+
+var (
+	zobristPieces      [2][6][64]uint64
+	zobristCastling    [4]uint64
+	zobristEnPassant   [8]uint64
+	zobristBlackToMove uint64
+)
+
+func init() {
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		return state * 0x2545f4914f6cdd1d
+	}
+
+	for color := 0; color < 2; color++ {
+		for piece := 0; piece < 6; piece++ {
+			for sq := 0; sq < 64; sq++ {
+				zobristPieces[color][piece][sq] = next()
+			}
+		}
+	}
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	zobristBlackToMove = next()
+}
+
+func (b *Board) castlingHashKey() uint64 {
+	var key uint64
+	if b.WhiteKingSideCastle {
+		key ^= zobristCastling[0]
+	}
+	if b.WhiteQueenSideCastle {
+		key ^= zobristCastling[1]
+	}
+	if b.BlackKingSideCastle {
+		key ^= zobristCastling[2]
+	}
+	if b.BlackQueenSideCastle {
+		key ^= zobristCastling[3]
+	}
+	return key
+}
+
+// The en passant file only enters the hash when a pawn of the side to move
+// could actually capture, so positions that differ only by an unusable en
+// passant square hash identically and repetitions are detected correctly.
+func (b *Board) epHashKey() uint64 {
+	if b.EnPassantSquare < 0 {
+		return 0
+	}
+
+	var capturers uint64
+	if b.WhiteToMove {
+		capturers = PawnAttackMasks[White][b.EnPassantSquare] & b.WhitePawns
+	} else {
+		capturers = PawnAttackMasks[Black][b.EnPassantSquare] & b.BlackPawns
+	}
+	if capturers == 0 {
+		return 0
+	}
+
+	return zobristEnPassant[b.EnPassantSquare%8]
+}
+
+func (b *Board) ComputeHash() uint64 {
+	var hash uint64
+
+	for _, color := range []Color{White, Black} {
+		for piece := Pawn; piece <= King; piece++ {
+			bitboard := *b.GetBitboard(piece, color)
+			for bitboard != 0 {
+				sq := bits.TrailingZeros64(bitboard)
+				bitboard &= bitboard - 1
+				hash ^= zobristPieces[color][piece][sq]
+			}
+		}
+	}
+
+	if !b.WhiteToMove {
+		hash ^= zobristBlackToMove
+	}
+
+	return hash ^ b.castlingHashKey() ^ b.epHashKey()
+}
+
+func (b *Board) VerifyHash() bool {
+	return b.Hash == b.ComputeHash()
+}
+
+func (b *Board) checkHash() {
+	if !b.VerifyHash() {
+		panic(fmt.Sprintf("zobrist hash mismatch for %s: have %016x, want %016x", b.ToFEN(), b.Hash, b.ComputeHash()))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"chess/chess"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	fen := flag.String("fen", chess.StartingFEN, "position to search")
+	depth := flag.Int("depth", 5, "perft depth")
+	moves := flag.String("moves", "", "space-separated UCI moves to play from the position first")
+	divide := flag.Bool("divide", false, "print the node count below each root move")
+	workers := flag.Int("workers", 0, "goroutines to split root moves across (0 uses GOMAXPROCS)")
+	flag.Parse()
+
+	board, err := chess.ParseFEN(*fen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, text := range strings.Fields(*moves) {
+		if len(text) != 4 && len(text) != 5 {
+			log.Fatalf("invalid move %s", text)
+		}
+		move, err := board.ValidateMove(text[0:2], text[2:4], text[4:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		board.MakeMove(move)
+	}
+
+	start := time.Now()
+	result := chess.DivideParallel(board, *depth, *workers)
+	elapsed := time.Since(start)
+
+	if *divide {
+		fmt.Print(result)
+	} else {
+		fmt.Printf("Nodes searched: %d\n", result.Nodes)
+	}
+
+	nps := float64(result.Nodes) / elapsed.Seconds()
+	fmt.Fprintf(os.Stderr, "time %v, %.0f nodes/s\n", elapsed.Round(time.Millisecond), nps)
+}
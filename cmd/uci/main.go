@@ -0,0 +1,14 @@
+package main
+
+import (
+	"chess/uci"
+	"log"
+	"os"
+)
+
+func main() {
+	session := uci.NewSession(os.Stdout)
+	if err := session.Run(os.Stdin); err != nil {
+		log.Fatal(err)
+	}
+}
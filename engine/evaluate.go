@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"chess/chess"
+	"math/bits"
+)
+
+var pieceValues = [...]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+var (
+	middlegameValues = [...]int{chess.Pawn: 82, chess.Knight: 337, chess.Bishop: 365, chess.Rook: 477, chess.Queen: 1025, chess.King: 0}
+	endgameValues    = [...]int{chess.Pawn: 94, chess.Knight: 281, chess.Bishop: 297, chess.Rook: 512, chess.Queen: 936, chess.King: 0}
+	phaseWeights     = [...]int{chess.Pawn: 0, chess.Knight: 1, chess.Bishop: 1, chess.Rook: 2, chess.Queen: 4, chess.King: 0}
+)
+
+const totalPhase = 24
+
+const (
+	doubledPawnMiddlegame  = -10
+	doubledPawnEndgame     = -20
+	isolatedPawnMiddlegame = -10
+	isolatedPawnEndgame    = -15
+	bishopPairMiddlegame   = 30
+	bishopPairEndgame      = 50
+	pawnShieldBonus        = 10
+	kingAttackWeight       = 7
+)
+
+var (
+	passedPawnMiddlegame = [8]int{0, 5, 10, 20, 35, 60, 100, 0}
+	passedPawnEndgame    = [8]int{0, 10, 20, 40, 70, 120, 200, 0}
+
+	mobilityMiddlegame = [...]int{chess.Knight: 4, chess.Bishop: 5, chess.Rook: 2, chess.Queen: 1}
+	mobilityEndgame    = [...]int{chess.Knight: 4, chess.Bishop: 5, chess.Rook: 4, chess.Queen: 2}
+	kingAttackUnits    = [...]int{chess.Knight: 2, chess.Bishop: 2, chess.Rook: 3, chess.Queen: 5}
+)
+
+// Piece-square tables are laid out as seen from white's side of the board,
+// a8 first and h1 last, so white squares are looked up with sq^56.
+var middlegameTables = [6][64]int{
+	chess.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	chess.Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	chess.Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	chess.Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	chess.Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	chess.King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+var endgameTables = [6][64]int{
+	chess.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		80, 80, 80, 80, 80, 80, 80, 80,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		30, 30, 30, 30, 30, 30, 30, 30,
+		15, 15, 15, 15, 15, 15, 15, 15,
+		5, 5, 5, 5, 5, 5, 5, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	chess.Knight: middlegameTables[chess.Knight],
+	chess.Bishop: middlegameTables[chess.Bishop],
+	chess.Rook:   middlegameTables[chess.Rook],
+	chess.Queen:  middlegameTables[chess.Queen],
+	chess.King: {
+		-50, -40, -30, -20, -20, -30, -40, -50,
+		-30, -20, -10, 0, 0, -10, -20, -30,
+		-30, -10, 20, 30, 30, 20, -10, -30,
+		-30, -10, 30, 40, 40, 30, -10, -30,
+		-30, -10, 30, 40, 40, 30, -10, -30,
+		-30, -10, 20, 30, 30, 20, -10, -30,
+		-30, -30, 0, 0, 0, 0, -30, -30,
+		-50, -30, -30, -30, -30, -30, -30, -50,
+	},
+}
+
+type score struct {
+	middlegame int
+	endgame    int
+}
+
+func (s *score) add(middlegame, endgame int) {
+	s.middlegame += middlegame
+	s.endgame += endgame
+}
+
+func Evaluate(board *chess.Board) int {
+	var white, black score
+	phase := 0
+
+	for piece := chess.Pawn; piece <= chess.King; piece++ {
+		phase += phaseWeights[piece] * bits.OnesCount64(*board.GetBitboard(piece, chess.White)|*board.GetBitboard(piece, chess.Black))
+	}
+	if phase > totalPhase {
+		phase = totalPhase
+	}
+
+	evaluateSide(board, chess.White, &white)
+	evaluateSide(board, chess.Black, &black)
+
+	middlegame := white.middlegame - black.middlegame
+	endgame := white.endgame - black.endgame
+
+	return (middlegame*phase + endgame*(totalPhase-phase)) / totalPhase
+}
+
+func evaluate(board *chess.Board) int {
+	if board.WhiteToMove {
+		return Evaluate(board)
+	}
+	return -Evaluate(board)
+}
+
+func relativeSquare(color chess.Color, square int) int {
+	if color == chess.White {
+		return square ^ 56
+	}
+	return square
+}
+
+func relativeRank(color chess.Color, square int) int {
+	if color == chess.White {
+		return square / 8
+	}
+	return 7 - square/8
+}
+
+func evaluateSide(board *chess.Board, color chess.Color, s *score) {
+	enemy := chess.Black
+	friendly, occupied := board.WhitePieces(), board.AllPieces()
+	if color == chess.Black {
+		enemy = chess.White
+		friendly = board.BlackPieces()
+	}
+
+	for piece := chess.Pawn; piece <= chess.King; piece++ {
+		bitboard := *board.GetBitboard(piece, color)
+		for bitboard != 0 {
+			square := bits.TrailingZeros64(bitboard)
+			bitboard &= bitboard - 1
+
+			index := relativeSquare(color, square)
+			s.add(middlegameValues[piece]+middlegameTables[piece][index], endgameValues[piece]+endgameTables[piece][index])
+		}
+	}
+
+	if bits.OnesCount64(*board.GetBitboard(chess.Bishop, color)) >= 2 {
+		s.add(bishopPairMiddlegame, bishopPairEndgame)
+	}
+
+	evaluatePawns(board, color, s)
+
+	enemyKingSquare := bits.TrailingZeros64(*board.GetBitboard(chess.King, enemy))
+	enemyKingZone := chess.KingAttackMasks[enemyKingSquare] | uint64(1)<<enemyKingSquare
+	attackUnits, attackers := 0, 0
+
+	for _, piece := range []chess.PieceType{chess.Knight, chess.Bishop, chess.Rook, chess.Queen} {
+		bitboard := *board.GetBitboard(piece, color)
+		for bitboard != 0 {
+			square := bits.TrailingZeros64(bitboard)
+			bitboard &= bitboard - 1
+
+			var moves uint64
+			switch piece {
+			case chess.Knight:
+				moves = chess.GenerateKnightMoves(square, friendly)
+			case chess.Bishop:
+				moves = chess.GenerateBishopMoves(square, occupied, friendly)
+			case chess.Rook:
+				moves = chess.GenerateRookMoves(square, occupied, friendly)
+			case chess.Queen:
+				moves = chess.GenerateQueenMoves(square, occupied, friendly)
+			}
+
+			mobility := bits.OnesCount64(moves)
+			s.add(mobilityMiddlegame[piece]*mobility, mobilityEndgame[piece]*mobility)
+
+			if zoneAttacks := bits.OnesCount64(moves & enemyKingZone); zoneAttacks > 0 {
+				attackers++
+				attackUnits += kingAttackUnits[piece] * zoneAttacks
+			}
+		}
+	}
+
+	if attackers >= 2 {
+		s.add(kingAttackWeight*attackUnits, 0)
+	}
+
+	kingSquare := bits.TrailingZeros64(*board.GetBitboard(chess.King, color))
+	if relativeRank(color, kingSquare) <= 1 {
+		shield := chess.KingAttackMasks[kingSquare] &^ chess.RankMasks[kingSquare/8]
+		if color == chess.White {
+			shield &= chess.NorthMasks[kingSquare] | chess.NorthEastMasks[kingSquare] | chess.NorthWestMasks[kingSquare]
+		} else {
+			shield &= chess.SouthMasks[kingSquare] | chess.SouthEastMasks[kingSquare] | chess.SouthWestMasks[kingSquare]
+		}
+		shieldPawns := bits.OnesCount64(shield & *board.GetBitboard(chess.Pawn, color))
+		s.add(pawnShieldBonus*shieldPawns, 0)
+	}
+}
+
+func evaluatePawns(board *chess.Board, color chess.Color, s *score) {
+	pawns := *board.GetBitboard(chess.Pawn, color)
+	enemyPawns := *board.GetBitboard(chess.Pawn, chess.White)
+	if color == chess.White {
+		enemyPawns = *board.GetBitboard(chess.Pawn, chess.Black)
+	}
+
+	for file := 0; file < 8; file++ {
+		filePawns := bits.OnesCount64(pawns & chess.FileMasks[file])
+		if filePawns == 0 {
+			continue
+		}
+
+		if filePawns > 1 {
+			s.add(doubledPawnMiddlegame*(filePawns-1), doubledPawnEndgame*(filePawns-1))
+		}
+
+		var adjacentFiles uint64
+		if file > 0 {
+			adjacentFiles |= chess.FileMasks[file-1]
+		}
+		if file < 7 {
+			adjacentFiles |= chess.FileMasks[file+1]
+		}
+		if pawns&adjacentFiles == 0 {
+			s.add(isolatedPawnMiddlegame*filePawns, isolatedPawnEndgame*filePawns)
+		}
+	}
+
+	for bitboard := pawns; bitboard != 0; bitboard &= bitboard - 1 {
+		square := bits.TrailingZeros64(bitboard)
+
+		var front uint64
+		if color == chess.White {
+			front = chess.NorthMasks[square]
+		} else {
+			front = chess.SouthMasks[square]
+		}
+		front |= (front&chess.NotHFile)<<1 | (front&chess.NotAFile)>>1
+
+		if front&enemyPawns == 0 {
+			rank := relativeRank(color, square)
+			s.add(passedPawnMiddlegame[rank], passedPawnEndgame[rank])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import "chess/chess"
+
+const (
+	pvMoveScore       = 1 << 20
+	ttMoveScore       = 1<<20 - 1
+	captureScore      = 1 << 16
+	promotionScore    = 1 << 15
+	firstKillerScore  = 1 << 14
+	secondKillerScore = 1<<14 - 1
+)
+
+func (s *searcher) scoreMoves(moves []chess.Move, ply int, ttMove chess.Move) []int {
+	scores := s.moveScores[ply][:len(moves)]
+
+	var pvMove chess.Move
+	if ply < len(s.rootPV) && s.followsRootPV(ply) {
+		pvMove = s.rootPV[ply]
+	}
+
+	for i, move := range moves {
+		switch {
+		case move == pvMove && pvMove != 0:
+			scores[i] = pvMoveScore
+		case move == ttMove && ttMove != 0:
+			scores[i] = ttMoveScore
+		case move.Flag()&chess.FlagCapture != 0:
+			// Captures that lose material go after the quiet moves.
+			if see := chess.SEE(&s.board, move); see < 0 {
+				scores[i] = see
+				continue
+			}
+			victim := chess.Pawn
+			if move.Flag() != chess.FlagEPCapture {
+				victim, _, _ = s.board.PieceAt(int(move.To()))
+			}
+			attacker, _, _ := s.board.PieceAt(int(move.From()))
+			scores[i] = captureScore + pieceValues[victim]*10 - pieceValues[attacker]/10
+		case move.PromotionPiece() != chess.NoPieceType:
+			scores[i] = promotionScore + pieceValues[move.PromotionPiece()]
+		case move == s.killers[ply][0]:
+			scores[i] = firstKillerScore
+		case move == s.killers[ply][1]:
+			scores[i] = secondKillerScore
+		default:
+			scores[i] = 0
+		}
+	}
+
+	return scores
+}
+
+func (s *searcher) followsRootPV(ply int) bool {
+	for i := 0; i < ply; i++ {
+		if s.path[i] != s.rootPV[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pickMove(moves []chess.Move, scores []int, index int) {
+	best := index
+	for i := index + 1; i < len(moves); i++ {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	moves[index], moves[best] = moves[best], moves[index]
+	scores[index], scores[best] = scores[best], scores[index]
+}
@@ -0,0 +1,548 @@
+package engine
+
+import (
+	"chess/chess"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	Infinity     = 32000
+	MateScore    = 30000
+	MaxPly       = 128
+	DefaultDepth = 6
+)
+
+const checkInterval = 2048
+
+type Limits struct {
+	Depth     int
+	Nodes     uint64
+	MoveTime  time.Duration
+	WhiteTime time.Duration
+	BlackTime time.Duration
+	WhiteInc  time.Duration
+	BlackInc  time.Duration
+	MovesToGo int
+	Infinite  bool
+	MultiPV   int
+
+	// History holds the hashes of the positions played before the searched
+	// one, oldest first, so that the search sees repetitions of them.
+	History []uint64
+}
+
+// Line is one of the best moves at the root with its score and principal
+// variation.
+type Line struct {
+	Score int
+	Mate  int
+	PV    []chess.Move
+}
+
+type SearchResult struct {
+	BestMove chess.Move
+	Score    int
+	Mate     int
+	Depth    int
+	Nodes    uint64
+	Time     time.Duration
+	PV       []chess.Move
+	Hashfull int
+
+	// Lines holds the best Limits.MultiPV root moves, best first. The first
+	// line is the one described by BestMove, Score, Mate and PV.
+	Lines []Line
+}
+
+// Engine searches positions one at a time. With Threads above one it runs a
+// Lazy SMP search: helper goroutines search the same position on their own
+// boards and share what they find through the transposition table, while the
+// main search alone decides the result. A single thread is deterministic.
+type Engine struct {
+	OnInfo  func(SearchResult)
+	TT      *TranspositionTable
+	Threads int
+
+	stopped atomic.Bool
+	nodes   atomic.Uint64
+}
+
+type searcher struct {
+	engine   *Engine
+	board    chess.Board
+	limits   Limits
+	start    time.Time
+	deadline time.Time
+	nodes    uint64
+	flushed  uint64
+	stopped  bool
+	helper   bool
+	finished *atomic.Bool
+	multiPV  int
+	excluded []chess.Move
+
+	history  []uint64
+	pvTable  [MaxPly][MaxPly]chess.Move
+	pvLength [MaxPly]int
+	killers  [MaxPly][2]chess.Move
+	path     [MaxPly]chess.Move
+	rootPV   []chess.Move
+
+	moveLists  [MaxPly]chess.MoveList
+	moveScores [MaxPly][chess.MaxMoves]int
+}
+
+func New() *Engine {
+	return &Engine{TT: NewTranspositionTable(DefaultHashSize)}
+}
+
+func Search(board *chess.Board, limits Limits) SearchResult {
+	return New().Search(board, limits)
+}
+
+func (e *Engine) Search(board *chess.Board, limits Limits) SearchResult {
+	e.stopped.Store(false)
+	return e.search(*board, limits)
+}
+
+// SearchContext is Search, stopped early when ctx is cancelled.
+func (e *Engine) SearchContext(ctx context.Context, board *chess.Board, limits Limits) SearchResult {
+	e.stopped.Store(false)
+	stop := context.AfterFunc(ctx, e.Stop)
+	defer stop()
+	return e.search(*board, limits)
+}
+
+func (e *Engine) Go(board *chess.Board, limits Limits) <-chan SearchResult {
+	e.stopped.Store(false)
+	position := board.Clone()
+	done := make(chan SearchResult, 1)
+	go func() {
+		done <- e.search(*position, limits)
+	}()
+	return done
+}
+
+func (e *Engine) Stop() {
+	e.stopped.Store(true)
+}
+
+func (e *Engine) search(board chess.Board, limits Limits) SearchResult {
+	var finished atomic.Bool
+	s := &searcher{
+		engine:   e,
+		board:    board,
+		limits:   limits,
+		start:    time.Now(),
+		finished: &finished,
+		history:  slices.Clone(limits.History),
+	}
+	s.setDeadline()
+	e.TT.NewSearch()
+	e.nodes.Store(0)
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 {
+		if limits.Infinite || limits.Nodes > 0 || !s.deadline.IsZero() {
+			maxDepth = MaxPly - 1
+		} else {
+			maxDepth = DefaultDepth
+		}
+	}
+	if maxDepth >= MaxPly {
+		maxDepth = MaxPly - 1
+	}
+
+	var result SearchResult
+	rootMoves := chess.GenerateAllLegalMoves(&s.board)
+	if len(rootMoves) == 0 {
+		if s.board.InCheck() {
+			result.Score = -MateScore
+		}
+		result.Time = time.Since(s.start)
+		return result
+	}
+	result.BestMove = rootMoves[0]
+	s.multiPV = min(max(limits.MultiPV, 1), len(rootMoves))
+
+	// Helpers are told to finish once the main search has, and half of them
+	// start a ply deeper so that the threads spread over more depths.
+	var helpers sync.WaitGroup
+	for id := 1; id < e.Threads; id++ {
+		helper := &searcher{
+			engine:   e,
+			board:    board,
+			limits:   limits,
+			start:    s.start,
+			deadline: s.deadline,
+			helper:   true,
+			finished: &finished,
+			multiPV:  1,
+			history:  slices.Clone(limits.History),
+		}
+		helpers.Add(1)
+		go func() {
+			defer helpers.Done()
+			helper.iterate(1+id%2, maxDepth, SearchResult{})
+		}()
+	}
+
+	result = s.iterate(1, maxDepth, result)
+	finished.Store(true)
+	helpers.Wait()
+
+	result.Nodes = e.nodes.Load()
+	result.Time = time.Since(s.start)
+	return result
+}
+
+// iterate deepens the search from depth first to maxDepth, reporting each
+// completed depth from the main search.
+func (s *searcher) iterate(first, maxDepth int, result SearchResult) SearchResult {
+	defer s.flushNodes()
+
+	var lines []Line
+	for depth := first; depth <= maxDepth; depth++ {
+		searched := s.searchLines(depth, lines)
+		if s.stopped && (depth > 1 || s.helper) {
+			break
+		}
+		lines = searched
+		if s.helper {
+			continue
+		}
+
+		best := lines[0]
+		result.Depth = depth
+		result.Score = best.Score
+		result.Mate = best.Mate
+		if len(best.PV) > 0 {
+			result.BestMove = best.PV[0]
+		}
+		result.PV = best.PV
+		result.Lines = lines
+		result.Nodes = s.totalNodes()
+		result.Time = time.Since(s.start)
+		result.Hashfull = s.engine.TT.Hashfull()
+
+		if s.engine.OnInfo != nil {
+			s.engine.OnInfo(result)
+		}
+
+		if s.stopped || (result.Mate != 0 && depth >= 2*abs(result.Mate)) {
+			break
+		}
+	}
+	return result
+}
+
+// searchLines searches the root to depth once for each of the multiPV best
+// moves, leaving out the moves already found, and returns the lines best
+// first. Each search starts from the same line of the previous depth.
+func (s *searcher) searchLines(depth int, previous []Line) []Line {
+	lines := make([]Line, 0, s.multiPV)
+	s.excluded = s.excluded[:0]
+	for k := 0; k < s.multiPV; k++ {
+		s.rootPV = s.rootPV[:0]
+		if k < len(previous) {
+			s.rootPV = append(s.rootPV, previous[k].PV...)
+		}
+
+		score := s.negamax(depth, 0, -Infinity, Infinity)
+		if s.stopped && k > 0 {
+			break
+		}
+		pv := append([]chess.Move(nil), s.pvTable[0][:s.pvLength[0]]...)
+		lines = append(lines, Line{Score: score, Mate: mateDistance(score), PV: pv})
+		if s.stopped || len(pv) == 0 {
+			break
+		}
+		s.excluded = append(s.excluded, pv[0])
+	}
+
+	slices.SortStableFunc(lines, func(a, b Line) int {
+		return b.Score - a.Score
+	})
+	return lines
+}
+
+func (s *searcher) setDeadline() {
+	if s.limits.Infinite {
+		return
+	}
+
+	if s.limits.MoveTime > 0 {
+		s.deadline = s.start.Add(s.limits.MoveTime)
+		return
+	}
+
+	remaining, increment := s.limits.BlackTime, s.limits.BlackInc
+	if s.board.WhiteToMove {
+		remaining, increment = s.limits.WhiteTime, s.limits.WhiteInc
+	}
+	if remaining <= 0 {
+		return
+	}
+
+	movesToGo := s.limits.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = 30
+	}
+
+	budget := remaining/time.Duration(movesToGo) + increment*3/4
+	if limit := remaining - 50*time.Millisecond; budget > limit {
+		budget = limit
+	}
+	if budget < 10*time.Millisecond {
+		budget = 10 * time.Millisecond
+	}
+	s.deadline = s.start.Add(budget)
+}
+
+func (s *searcher) checkLimits() {
+	if s.limits.Nodes > 0 && s.totalNodes() >= s.limits.Nodes {
+		s.stopped = true
+	}
+	if s.nodes%checkInterval == 0 {
+		s.flushNodes()
+		if s.engine.stopped.Load() || s.finished.Load() || (!s.deadline.IsZero() && time.Now().After(s.deadline)) {
+			s.stopped = true
+		}
+	}
+}
+
+// flushNodes adds the nodes searched since the last flush to the engine's
+// count across all threads.
+func (s *searcher) flushNodes() {
+	s.engine.nodes.Add(s.nodes - s.flushed)
+	s.flushed = s.nodes
+}
+
+func (s *searcher) totalNodes() uint64 {
+	return s.engine.nodes.Load() + s.nodes - s.flushed
+}
+
+func (s *searcher) isDraw() bool {
+	if s.board.HalfmoveClock >= 100 || s.board.IsInsufficientMaterial() {
+		return true
+	}
+
+	hash := s.board.Hash
+	limit := len(s.history) - s.board.HalfmoveClock
+	for i := len(s.history) - 2; i >= 0 && i >= limit; i -= 2 {
+		if s.history[i] == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *searcher) negamax(depth, ply, alpha, beta int) int {
+	s.pvLength[ply] = ply
+
+	s.checkLimits()
+	if s.stopped {
+		return 0
+	}
+
+	if ply > 0 && s.isDraw() {
+		return 0
+	}
+	if ply >= MaxPly-1 {
+		return evaluate(&s.board)
+	}
+
+	inCheck := s.board.InCheck()
+	if inCheck {
+		depth++
+	}
+	if depth <= 0 {
+		return s.quiescence(ply, alpha, beta)
+	}
+
+	s.nodes++
+
+	var ttMove chess.Move
+	if entry, ok := s.engine.TT.Probe(s.board.Hash, ply); ok {
+		ttMove = entry.Move
+		if ply > 0 && entry.Depth >= depth {
+			switch {
+			case entry.Bound == BoundExact,
+				entry.Bound == BoundLower && entry.Score >= beta,
+				entry.Bound == BoundUpper && entry.Score <= alpha:
+				return entry.Score
+			}
+		}
+	}
+
+	list := &s.moveLists[ply]
+	s.board.GenerateLegalMoves(list)
+	moves := list.Moves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply
+		}
+		return 0
+	}
+
+	scores := s.scoreMoves(moves, ply, ttMove)
+	bestScore := -Infinity
+	var bestMove chess.Move
+	bound := BoundUpper
+
+	for i := range moves {
+		pickMove(moves, scores, i)
+		move := moves[i]
+		if ply == 0 && slices.Contains(s.excluded, move) {
+			continue
+		}
+
+		s.path[ply] = move
+		s.history = append(s.history, s.board.Hash)
+		undoInfo := s.board.MakeMove(move)
+		score := -s.negamax(depth-1, ply+1, -beta, -alpha)
+		s.board.UndoMove(move, undoInfo)
+		s.history = s.history[:len(s.history)-1]
+
+		if s.stopped {
+			return 0
+		}
+
+		if score > bestScore {
+			bestScore = score
+			bestMove = move
+		}
+		if score > alpha {
+			alpha = score
+			bound = BoundExact
+			s.updatePV(ply, move)
+
+			if alpha >= beta {
+				bound = BoundLower
+				if move.Flag()&chess.FlagCapture == 0 {
+					s.storeKiller(ply, move)
+				}
+				break
+			}
+		}
+	}
+
+	// A root searched without some of its moves has no score of its own.
+	if ply > 0 || len(s.excluded) == 0 {
+		s.engine.TT.Store(s.board.Hash, bestMove, bestScore, depth, ply, bound)
+	}
+	return bestScore
+}
+
+func (s *searcher) quiescence(ply, alpha, beta int) int {
+	s.pvLength[ply] = ply
+
+	s.checkLimits()
+	if s.stopped {
+		return 0
+	}
+
+	s.nodes++
+
+	if ply >= MaxPly-1 {
+		return evaluate(&s.board)
+	}
+
+	inCheck := s.board.InCheck()
+	bestScore := -Infinity
+	if !inCheck {
+		bestScore = evaluate(&s.board)
+		if bestScore >= beta {
+			return bestScore
+		}
+		if bestScore > alpha {
+			alpha = bestScore
+		}
+	}
+
+	list := &s.moveLists[ply]
+	s.board.GenerateLegalMoves(list)
+	moves := list.Moves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply
+		}
+		return bestScore
+	}
+
+	if !inCheck {
+		tactical := moves[:0]
+		for _, move := range moves {
+			if move.PromotionPiece() != chess.NoPieceType ||
+				move.Flag()&chess.FlagCapture != 0 && chess.SEE(&s.board, move) >= 0 {
+				tactical = append(tactical, move)
+			}
+		}
+		moves = tactical
+	}
+
+	scores := s.scoreMoves(moves, ply, 0)
+
+	for i := range moves {
+		pickMove(moves, scores, i)
+		move := moves[i]
+
+		s.path[ply] = move
+		undoInfo := s.board.MakeMove(move)
+		score := -s.quiescence(ply+1, -beta, -alpha)
+		s.board.UndoMove(move, undoInfo)
+
+		if s.stopped {
+			return 0
+		}
+
+		if score > bestScore {
+			bestScore = score
+		}
+		if score > alpha {
+			alpha = score
+			s.updatePV(ply, move)
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+
+	return bestScore
+}
+
+func (s *searcher) updatePV(ply int, move chess.Move) {
+	s.pvTable[ply][ply] = move
+	for next := ply + 1; next < s.pvLength[ply+1]; next++ {
+		s.pvTable[ply][next] = s.pvTable[ply+1][next]
+	}
+	s.pvLength[ply] = s.pvLength[ply+1]
+}
+
+func (s *searcher) storeKiller(ply int, move chess.Move) {
+	if s.killers[ply][0] != move {
+		s.killers[ply][1] = s.killers[ply][0]
+		s.killers[ply][0] = move
+	}
+}
+
+func mateDistance(score int) int {
+	if score >= MateScore-MaxPly {
+		return (MateScore - score + 1) / 2
+	}
+	if score <= -MateScore+MaxPly {
+		return -(MateScore + score) / 2
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
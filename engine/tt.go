@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"chess/chess"
+	"sync/atomic"
+)
+
+const DefaultHashSize = 16
+
+type Bound uint8
+
+const (
+	BoundNone Bound = iota
+	BoundUpper
+	BoundLower
+	BoundExact
+)
+
+type TTEntry struct {
+	Move  chess.Move
+	Score int
+	Depth int
+	Bound Bound
+}
+
+type TTStats struct {
+	Probes     uint64
+	Hits       uint64
+	Stores     uint64
+	Overwrites uint64
+}
+
+func (s TTStats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Probes)
+}
+
+// ttSlot stores an entry packed into data alongside the hash xored with the
+// data, so that a slot torn by a concurrent write fails the key check instead
+// of returning another position's entry.
+type ttSlot struct {
+	key  atomic.Uint64
+	data atomic.Uint64
+}
+
+const bucketSize = 4
+
+// A bucket fills one 64-byte cache line.
+type ttBucket [bucketSize]ttSlot
+
+// TranspositionTable caches search results by Zobrist hash. It is safe for
+// concurrent use without locks; a lost or torn write only costs a re-search.
+type TranspositionTable struct {
+	buckets    []ttBucket
+	mask       uint64
+	generation uint8
+
+	probes     atomic.Uint64
+	hits       atomic.Uint64
+	stores     atomic.Uint64
+	overwrites atomic.Uint64
+}
+
+func NewTranspositionTable(sizeMB int) *TranspositionTable {
+	t := &TranspositionTable{}
+	t.Resize(sizeMB)
+	return t
+}
+
+// Resize reallocates the table to the largest power of two number of buckets
+// that fits in sizeMB megabytes, discarding its contents. It must not be
+// called during a search.
+func (t *TranspositionTable) Resize(sizeMB int) {
+	if sizeMB < 1 {
+		sizeMB = 1
+	}
+	count := uint64(1)
+	for count*2*64 <= uint64(sizeMB)<<20 {
+		count *= 2
+	}
+	t.buckets = make([]ttBucket, count)
+	t.mask = count - 1
+	t.generation = 0
+	t.resetStats()
+}
+
+func (t *TranspositionTable) Clear() {
+	clear(t.buckets)
+	t.generation = 0
+	t.resetStats()
+}
+
+// SizeMB returns the memory the table's entries take up.
+func (t *TranspositionTable) SizeMB() int {
+	return len(t.buckets) * 64 >> 20
+}
+
+// NewSearch ages every stored entry by one search so that entries from earlier
+// searches are replaced first. It must not be called during a search.
+func (t *TranspositionTable) NewSearch() {
+	t.generation++
+}
+
+func (t *TranspositionTable) Stats() TTStats {
+	return TTStats{
+		Probes:     t.probes.Load(),
+		Hits:       t.hits.Load(),
+		Stores:     t.stores.Load(),
+		Overwrites: t.overwrites.Load(),
+	}
+}
+
+func (t *TranspositionTable) resetStats() {
+	t.probes.Store(0)
+	t.hits.Store(0)
+	t.stores.Store(0)
+	t.overwrites.Store(0)
+}
+
+// Hashfull estimates in permille how much of the table the current search has
+// filled, as reported by UCI.
+func (t *TranspositionTable) Hashfull() int {
+	buckets := min(len(t.buckets), 1000/bucketSize)
+	used := 0
+	for i := 0; i < buckets; i++ {
+		for j := range t.buckets[i] {
+			if data := t.buckets[i][j].data.Load(); data != 0 && unpackGeneration(data) == t.generation {
+				used++
+			}
+		}
+	}
+	return used * 1000 / (buckets * bucketSize)
+}
+
+// Probe looks up hash, converting a mate score back to be relative to ply.
+func (t *TranspositionTable) Probe(hash uint64, ply int) (TTEntry, bool) {
+	t.probes.Add(1)
+	bucket := &t.buckets[hash&t.mask]
+	for i := range bucket {
+		data := bucket[i].data.Load()
+		if data == 0 || bucket[i].key.Load()^data != hash {
+			continue
+		}
+		t.hits.Add(1)
+		entry := unpackEntry(data)
+		entry.Score = scoreFromTT(entry.Score, ply)
+		return entry, true
+	}
+	return TTEntry{}, false
+}
+
+// Store records a search result for hash. A mate score is stored relative to
+// the position rather than the root so that it is valid wherever the position
+// is reached. Within a bucket the slot for the same position is reused, and
+// otherwise the shallowest entry, with older searches counting as shallower.
+func (t *TranspositionTable) Store(hash uint64, move chess.Move, score, depth, ply int, bound Bound) {
+	bucket := &t.buckets[hash&t.mask]
+	replace := -1
+	worst := 0
+	for i := range bucket {
+		data := bucket[i].data.Load()
+		if data == 0 {
+			replace = i
+			break
+		}
+		if bucket[i].key.Load()^data == hash {
+			old := unpackEntry(data)
+			if bound != BoundExact && depth < old.Depth-2 && unpackGeneration(data) == t.generation {
+				return
+			}
+			if move == 0 {
+				move = old.Move
+			}
+			replace = i
+			break
+		}
+
+		age := int(t.generation - unpackGeneration(data))
+		if value := int(uint8(data>>32)) - 8*age; replace < 0 || value < worst {
+			replace, worst = i, value
+		}
+	}
+
+	slot := &bucket[replace]
+	old := slot.data.Load()
+	if old != 0 && slot.key.Load()^old != hash {
+		t.overwrites.Add(1)
+	}
+	t.stores.Add(1)
+
+	data := packEntry(move, scoreToTT(score, ply), depth, bound, t.generation)
+	slot.key.Store(hash ^ data)
+	slot.data.Store(data)
+}
+
+// An entry packs the move into bits 0-15, the score into 16-31, the depth
+// into 32-39, the bound into 40-47 and the generation into 48-55.
+func packEntry(move chess.Move, score, depth int, bound Bound, generation uint8) uint64 {
+	return uint64(move) |
+		uint64(uint16(int16(score)))<<16 |
+		uint64(uint8(max(depth, 0)))<<32 |
+		uint64(bound)<<40 |
+		uint64(generation)<<48
+}
+
+func unpackEntry(data uint64) TTEntry {
+	return TTEntry{
+		Move:  chess.Move(data),
+		Score: int(int16(data >> 16)),
+		Depth: int(uint8(data >> 32)),
+		Bound: Bound(data >> 40),
+	}
+}
+
+func unpackGeneration(data uint64) uint8 {
+	return uint8(data >> 48)
+}
+
+func scoreToTT(score, ply int) int {
+	if score >= MateScore-MaxPly {
+		return score + ply
+	}
+	if score <= -MateScore+MaxPly {
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score, ply int) int {
+	if score >= MateScore-MaxPly {
+		return score - ply
+	}
+	if score <= -MateScore+MaxPly {
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"chess/chess"
+	"chess/engine"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxAnalysisLines = 10
+	// maxAnalysisTime stays within the analyze function's maxDuration in
+	// vercel.json.
+	maxAnalysisTime = 50 * time.Second
+)
+
+// AnalysisLine is one line of play found by the analysis, scored like
+// BestMoveResponse.
+type AnalysisLine struct {
+	Move  string   `json:"move"`
+	SAN   string   `json:"san"`
+	Score int      `json:"score"`
+	Mate  int      `json:"mate,omitempty"`
+	PV    []string `json:"pv"`
+	PVSAN []string `json:"pv_san"`
+}
+
+// AnalysisInfo is sent as an "info" event each time the analysis completes a
+// depth, and as the final "done" event. Lines holds the best moves asked for
+// with the multipv query parameter, best first.
+type AnalysisInfo struct {
+	Depth int            `json:"depth"`
+	Nodes uint64         `json:"nodes"`
+	NPS   uint64         `json:"nps"`
+	Time  int64          `json:"time_ms"`
+	Lines []AnalysisLine `json:"lines"`
+}
+
+type AnalysisStart struct {
+	FEN string `json:"fen"`
+}
+
+// HandleAnalyze streams an infinite analysis of the position given by the
+// game_id query parameter, or by fen and the space-separated UCI moves played
+// from it, following the best multipv moves (one by default), as Server-Sent
+// Events. It opens with a "start" event, sends an "info" event per completed
+// depth and runs until the client disconnects, which is how clients stop it,
+// or for movetime milliseconds, at most maxAnalysisTime. Should the search end
+// first, the stream closes with a "done" event. It fails with 503 when the
+// server is running too many searches.
+func HandleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	board, history, code, err := searchPosition(query.Get("game_id"), query.Get("fen"), strings.Fields(query.Get("moves")))
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	multiPV := 1
+	if value := query.Get("multipv"); value != "" {
+		multiPV, err = strconv.Atoi(value)
+		if err != nil || multiPV < 1 || multiPV > maxAnalysisLines {
+			http.Error(w, fmt.Sprintf("multipv must be between 1 and %d", maxAnalysisLines), http.StatusBadRequest)
+			return
+		}
+	}
+
+	moveTime := maxAnalysisTime
+	if value := query.Get("movetime"); value != "" {
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms < 1 || time.Duration(ms)*time.Millisecond > maxAnalysisTime {
+			http.Error(w, fmt.Sprintf("movetime must be between 1 and %d", maxAnalysisTime.Milliseconds()), http.StatusBadRequest)
+			return
+		}
+		moveTime = time.Duration(ms) * time.Millisecond
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	e, ok := acquireSearch()
+	if !ok {
+		http.Error(w, "Too many searches running", http.StatusServiceUnavailable)
+		return
+	}
+	defer releaseSearch(e)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	send("start", AnalysisStart{FEN: board.ToFEN()})
+
+	// OnInfo runs on the goroutine calling SearchContext, so events are
+	// written in order from this handler.
+	e.OnInfo = func(result engine.SearchResult) {
+		send("info", newAnalysisInfo(board, result))
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), moveTime)
+	defer cancel()
+	result := e.SearchContext(ctx, board, engine.Limits{Infinite: true, MultiPV: multiPV, History: history})
+
+	if r.Context().Err() == nil {
+		send("done", newAnalysisInfo(board, result))
+	}
+}
+
+func newAnalysisInfo(board *chess.Board, result engine.SearchResult) AnalysisInfo {
+	info := AnalysisInfo{
+		Depth: result.Depth,
+		Nodes: result.Nodes,
+		Time:  result.Time.Milliseconds(),
+	}
+	if info.Time > 0 {
+		info.NPS = result.Nodes * 1000 / uint64(info.Time)
+	}
+
+	for _, pvLine := range result.Lines {
+		if len(pvLine.PV) == 0 {
+			continue
+		}
+		line := AnalysisLine{
+			Move:  pvLine.PV[0].ToString(),
+			SAN:   board.MoveToSAN(pvLine.PV[0]),
+			Score: pvLine.Score,
+			Mate:  pvLine.Mate,
+		}
+		line.PV, line.PVSAN = pvStrings(board, pvLine.PV)
+		info.Lines = append(info.Lines, line)
+	}
+	return info
+}
@@ -3,68 +3,136 @@ package handlers
 import (
 	"chess/chess"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 )
 
-var board chess.Board
+var games = NewGameStore(DefaultGameTTL)
 
-func GetBoard() *chess.Board {
-	return &board
-}
-
-func SetBoard(b chess.Board) {
-	board = b
+type GameStatus struct {
+	Over        bool   `json:"over"`
+	Termination string `json:"termination"`
+	Result      string `json:"result"`
+	Winner      string `json:"winner,omitempty"`
+	Claimable   string `json:"claimable,omitempty"`
 }
 
 type GameState struct {
-	FEN        string   `json:"fen"`
-	MoveCount  int      `json:"move_count"`
-	LegalMoves []string `json:"legal_moves"`
+	GameID        string      `json:"game_id,omitempty"`
+	FEN           string      `json:"fen"`
+	MoveCount     int         `json:"move_count"`
+	LegalMoves    []string    `json:"legal_moves"`
+	LegalMovesSAN []string    `json:"legal_moves_san"`
+	Status        *GameStatus `json:"status,omitempty"`
 }
 
+// MoveRequest plays a move in a stored game, or without a game id in the game
+// rebuilt from FEN and Moves as for UndoRequest, so that repetitions of
+// earlier positions are reported.
 type MoveRequest struct {
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Promotion string `json:"promotion,omitempty"`
-	FEN       string `json:"fen,omitempty"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Promotion string   `json:"promotion,omitempty"`
+	FEN       string   `json:"fen,omitempty"`
+	Moves     []string `json:"moves,omitempty"`
+	GameID    string   `json:"game_id,omitempty"`
+}
+
+// DrawRequest claims a draw in a stored game, or in the game rebuilt from FEN
+// and Moves as for UndoRequest. When Move is set the claim is made for the
+// position after it, which is played even if the claim is rejected.
+type DrawRequest struct {
+	GameID string   `json:"game_id,omitempty"`
+	FEN    string   `json:"fen,omitempty"`
+	Moves  []string `json:"moves,omitempty"`
+	Move   string   `json:"move,omitempty"`
+}
+
+// UndoRequest takes back the last move of a stored game, or without a game
+// id of the game made of Moves (in UCI notation) played from FEN, or from
+// the starting position when FEN is empty.
+type UndoRequest struct {
+	GameID string   `json:"game_id,omitempty"`
+	FEN    string   `json:"fen,omitempty"`
+	Moves  []string `json:"moves,omitempty"`
 }
 
 type MoveResponse struct {
-	Success    bool     `json:"success"`
-	Message    string   `json:"message,omitempty"`
-	FEN        string   `json:"fen,omitempty"`
-	LegalMoves []string `json:"legal_moves,omitempty"`
+	Success       bool        `json:"success"`
+	Message       string      `json:"message,omitempty"`
+	GameID        string      `json:"game_id,omitempty"`
+	Move          string      `json:"move,omitempty"`
+	SAN           string      `json:"san,omitempty"`
+	FEN           string      `json:"fen,omitempty"`
+	LegalMoves    []string    `json:"legal_moves,omitempty"`
+	LegalMovesSAN []string    `json:"legal_moves_san,omitempty"`
+	Status        *GameStatus `json:"status,omitempty"`
 }
 
-func HandleStartGame(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+func NewGameStatus(result chess.GameResult) *GameStatus {
+	status := &GameStatus{
+		Over:        result.IsOver(),
+		Termination: result.Termination.String(),
+		Result:      result.String(),
 	}
+	if winner, ok := result.Winner(); ok {
+		status.Winner = winner.String()
+	}
+	return status
+}
 
-	board = *chess.NewBoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
-
-	legalMoves := chess.GenerateAllLegalMoves(&board)
+func legalMoveStrings(board *chess.Board) ([]string, []string) {
+	legalMoves := chess.GenerateAllLegalMoves(board)
 	moveStrings := make([]string, len(legalMoves))
-
+	sanStrings := make([]string, len(legalMoves))
 	for i, move := range legalMoves {
 		moveStrings[i] = move.ToString()
+		sanStrings[i] = board.MoveToSAN(move)
 	}
+	return moveStrings, sanStrings
+}
 
-	response := GameState {
-		FEN: board.ToFEN(),
-		MoveCount: len(legalMoves),
-		LegalMoves: moveStrings,
+func gameStatus(game *chess.Game) *GameStatus {
+	status := NewGameStatus(game.Status())
+	if termination, ok := game.ClaimableDraw(); ok {
+		status.Claimable = termination.String()
 	}
+	return status
+}
 
+func newGameState(gameID string, game *chess.Game) GameState {
+	board := game.Board()
+	moveStrings, sanStrings := legalMoveStrings(board)
+	return GameState{
+		GameID:        gameID,
+		FEN:           board.ToFEN(),
+		MoveCount:     len(moveStrings),
+		LegalMoves:    moveStrings,
+		LegalMovesSAN: sanStrings,
+		Status:        gameStatus(game),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding reponse: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+func HandleStartGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	game := chess.NewGame(chess.MustParseFEN(chess.StartingFEN))
+	gameID := games.Create(game)
+
+	writeJSON(w, http.StatusOK, newGameState(gameID, game))
 }
 
 func HandleGetMoves(w http.ResponseWriter, r *http.Request) {
@@ -73,24 +141,32 @@ func HandleGetMoves(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	legalMoves := chess.GenerateAllLegalMoves(&board)
-	moveStrings := make([]string, len(legalMoves))
-	for i, move := range legalMoves {
-		moveStrings[i] = move.ToString()
-	}
+	query := r.URL.Query()
+	gameID := query.Get("game_id")
 
-	response := GameState{
-		FEN:        board.ToFEN(),
-		MoveCount:  0,
-		LegalMoves: moveStrings,
+	var state GameState
+	switch {
+	case gameID != "":
+		err := games.Update(gameID, func(game *chess.Game) error {
+			state = newGameState(gameID, game)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	case query.Get("fen") != "":
+		game, err := chess.NewGameFromFEN(query.Get("fen"))
+		if err != nil {
+			http.Error(w, "Invalid FEN: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		state = newGameState(gameID, game)
+	default:
+		state = newGameState(gameID, chess.NewGame(chess.MustParseFEN(chess.StartingFEN)))
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+	writeJSON(w, http.StatusOK, state)
 }
 
 func HandlePostMove(w http.ResponseWriter, r *http.Request) {
@@ -105,52 +181,193 @@ func HandlePostMove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if moveReq.FEN != "" {
-		board = *chess.NewBoardFromFEN(moveReq.FEN)
-	} else {
-		board = *chess.NewBoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	if moveReq.GameID != "" {
+		var response MoveResponse
+		err := games.Update(moveReq.GameID, func(game *chess.Game) error {
+			var err error
+			response, err = applyMove(game, moveReq)
+			return err
+		})
+		switch {
+		case err == ErrGameNotFound:
+			writeJSON(w, http.StatusNotFound, MoveResponse{Success: false, Message: err.Error()})
+		case err != nil:
+			writeJSON(w, http.StatusBadRequest, MoveResponse{Success: false, Message: err.Error()})
+		default:
+			response.GameID = moveReq.GameID
+			writeJSON(w, http.StatusOK, response)
+		}
+		return
 	}
 
-	var move chess.Move
-	var err error
-	if moveReq.Promotion != "" {
-		move, err = board.ValidateMove(moveReq.From, moveReq.To, moveReq.Promotion)
-	} else {
-		move, err = board.ValidateMove(moveReq.From, moveReq.To)
+	game, err := replayGame(moveReq.FEN, moveReq.Moves)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, MoveResponse{Success: false, Message: err.Error()})
+		return
 	}
+
+	response, err := applyMove(game, moveReq)
 	if err != nil {
-		response := MoveResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("Error encoding response: %v", err)
-		}
+		writeJSON(w, http.StatusBadRequest, MoveResponse{Success: false, Message: err.Error()})
 		return
 	}
+	writeJSON(w, http.StatusOK, response)
+}
 
-	board.MakeMove(move)
+func applyMove(game *chess.Game, moveReq MoveRequest) (MoveResponse, error) {
+	board := game.Board()
+	move, err := board.ValidateMove(moveReq.From, moveReq.To, moveReq.Promotion)
+	if err != nil {
+		return MoveResponse{}, err
+	}
 
-	legalMoves := chess.GenerateAllLegalMoves(&board)
-	moveStrings := make([]string, len(legalMoves))
-	for i, m := range legalMoves {
-		moveStrings[i] = m.ToString()
+	san := board.MoveToSAN(move)
+	if err := game.Push(move); err != nil {
+		return MoveResponse{}, err
 	}
 
+	return newMoveResponse("Move applied successfully", move, san, game), nil
+}
+
+// newMoveResponse describes game after move, which is left out of the
+// response when it is zero.
+func newMoveResponse(message string, move chess.Move, san string, game *chess.Game) MoveResponse {
+	board := game.Board()
+	moveStrings, sanStrings := legalMoveStrings(board)
 	response := MoveResponse{
-		Success:    true,
-		Message:    "Move applied successfully",
-		FEN:        board.ToFEN(),
-		LegalMoves: moveStrings,
+		Success:       true,
+		Message:       message,
+		SAN:           san,
+		FEN:           board.ToFEN(),
+		LegalMoves:    moveStrings,
+		LegalMovesSAN: sanStrings,
+		Status:        gameStatus(game),
 	}
+	if move != 0 {
+		response.Move = move.ToString()
+	}
+	return response
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+func HandleUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+
+	var undoReq UndoRequest
+	if err := json.NewDecoder(r.Body).Decode(&undoReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	runGameAction(w, undoReq.GameID, undoReq.FEN, undoReq.Moves, undoMove)
+}
+
+func HandleDraw(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var drawReq DrawRequest
+	if err := json.NewDecoder(r.Body).Decode(&drawReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	runGameAction(w, drawReq.GameID, drawReq.FEN, drawReq.Moves, func(game *chess.Game) (MoveResponse, error) {
+		return claimDraw(game, drawReq.Move)
+	})
+}
+
+// runGameAction applies action to the stored game gameID, or to the game
+// rebuilt from fen and moves when there is no id, and writes the response.
+// A failed action may still return the position if it changed the game.
+func runGameAction(w http.ResponseWriter, gameID, fen string, moves []string, action func(game *chess.Game) (MoveResponse, error)) {
+	var response MoveResponse
+	var err error
+	if gameID != "" {
+		err = games.Update(gameID, func(game *chess.Game) error {
+			var actionErr error
+			response, actionErr = action(game)
+			return actionErr
+		})
+	} else {
+		var game *chess.Game
+		if game, err = replayGame(fen, moves); err == nil {
+			response, err = action(game)
+		}
+	}
+
+	switch {
+	case err == ErrGameNotFound:
+		writeJSON(w, http.StatusNotFound, MoveResponse{Success: false, Message: err.Error()})
+	case err != nil:
+		response.Success = false
+		response.Message = err.Error()
+		writeJSON(w, http.StatusBadRequest, response)
+	default:
+		response.GameID = gameID
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+func replayGame(fen string, moves []string) (*chess.Game, error) {
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+	game, err := chess.NewGameFromFEN(fen)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid FEN: %w", err)
+	}
+
+	for _, text := range moves {
+		move, err := parseUCIMove(game.Board(), text)
+		if err != nil {
+			return nil, err
+		}
+		if err := game.Push(move); err != nil {
+			return nil, err
+		}
+	}
+	return game, nil
 }
 
+func parseUCIMove(board *chess.Board, text string) (chess.Move, error) {
+	if len(text) != 4 && len(text) != 5 {
+		return 0, fmt.Errorf("invalid move %q", text)
+	}
+	return board.ValidateMove(text[0:2], text[2:4], text[4:])
+}
+
+func undoMove(game *chess.Game) (MoveResponse, error) {
+	move, ok := game.Pop()
+	if !ok {
+		return MoveResponse{}, errors.New("no moves to take back")
+	}
+
+	return newMoveResponse("Move taken back", move, game.Board().MoveToSAN(move), game), nil
+}
+
+func claimDraw(game *chess.Game, moveText string) (MoveResponse, error) {
+	var move chess.Move
+	var san string
+	if moveText != "" {
+		var err error
+		if move, err = parseUCIMove(game.Board(), moveText); err != nil {
+			return MoveResponse{}, err
+		}
+		san = game.Board().MoveToSAN(move)
+		if err := game.Push(move); err != nil {
+			return MoveResponse{}, err
+		}
+	}
+
+	_, err := game.ClaimDraw()
+	response := newMoveResponse("Draw claimed", move, san, game)
+	if err != nil && moveText == "" {
+		return MoveResponse{}, err
+	}
+	return response, err
+}
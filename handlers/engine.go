@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"chess/chess"
+	"chess/engine"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+const (
+	maxSearchDepth = 20
+	maxSearchTime  = 10 * time.Second
+
+	// maxSearches bounds how many engine searches the server runs at once,
+	// and searchThreads how many threads each of them uses. Each search slot
+	// keeps a transposition table of searchHashSize MB for reuse.
+	maxSearches    = 4
+	searchThreads  = 2
+	searchHashSize = 4
+)
+
+// searchSlots holds the table of each free search slot, nil until a search
+// first uses the slot.
+var searchSlots = func() chan *engine.TranspositionTable {
+	slots := make(chan *engine.TranspositionTable, maxSearches)
+	for range maxSearches {
+		slots <- nil
+	}
+	return slots
+}()
+
+// acquireSearch claims one of the maxSearches search slots and returns an
+// engine using its table, reporting false without waiting when all are taken.
+// The slot is given back with releaseSearch.
+func acquireSearch() (*engine.Engine, bool) {
+	select {
+	case tt := <-searchSlots:
+		if tt == nil {
+			tt = engine.NewTranspositionTable(searchHashSize)
+		}
+		return &engine.Engine{TT: tt, Threads: min(searchThreads, runtime.GOMAXPROCS(0))}, true
+	default:
+		return nil, false
+	}
+}
+
+func releaseSearch(e *engine.Engine) {
+	searchSlots <- e.TT
+}
+
+// BestMoveRequest asks the engine for a move in a stored game, or in the game
+// made of Moves (in UCI notation) played from FEN, or from the starting
+// position when FEN is empty. The moves let the engine see repetitions.
+// MoveTime is in milliseconds. Searches
+// stop at maxSearchTime whatever the limits, and at engine.DefaultDepth when
+// neither limit is given. They share the server's maxSearches search slots
+// with analyses and fail with 503 when none is free.
+type BestMoveRequest struct {
+	GameID   string   `json:"game_id,omitempty"`
+	FEN      string   `json:"fen,omitempty"`
+	Moves    []string `json:"moves,omitempty"`
+	Depth    int      `json:"depth,omitempty"`
+	MoveTime int      `json:"movetime,omitempty"`
+}
+
+// BestMoveResponse reports the engine's choice. Score is in centipawns from
+// the point of view of the side to move, and Mate, when set, is the number of
+// moves to mate, negative when the side to move is being mated.
+type BestMoveResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message,omitempty"`
+	Move    string   `json:"move,omitempty"`
+	SAN     string   `json:"san,omitempty"`
+	Score   int      `json:"score"`
+	Mate    int      `json:"mate,omitempty"`
+	Depth   int      `json:"depth"`
+	Nodes   uint64   `json:"nodes"`
+	Time    int64    `json:"time_ms"`
+	PV      []string `json:"pv,omitempty"`
+	PVSAN   []string `json:"pv_san,omitempty"`
+}
+
+func HandleBestMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var bestMoveReq BestMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&bestMoveReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if bestMoveReq.Depth < 0 || bestMoveReq.Depth > maxSearchDepth || bestMoveReq.MoveTime < 0 {
+		writeJSON(w, http.StatusBadRequest, BestMoveResponse{Success: false, Message: "Invalid search limits"})
+		return
+	}
+
+	board, history, code, err := searchPosition(bestMoveReq.GameID, bestMoveReq.FEN, bestMoveReq.Moves)
+	if err != nil {
+		writeJSON(w, code, BestMoveResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	limits := engine.Limits{
+		Depth:    bestMoveReq.Depth,
+		MoveTime: time.Duration(bestMoveReq.MoveTime) * time.Millisecond,
+		History:  history,
+	}
+	if limits.MoveTime == 0 || limits.MoveTime > maxSearchTime {
+		limits.MoveTime = maxSearchTime
+	}
+	if bestMoveReq.Depth == 0 && bestMoveReq.MoveTime == 0 {
+		limits.Depth = engine.DefaultDepth
+	}
+
+	e, ok := acquireSearch()
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, BestMoveResponse{Success: false, Message: "Too many searches running"})
+		return
+	}
+	defer releaseSearch(e)
+
+	// The search stops early if the client goes away.
+	search := e.SearchContext(r.Context(), board, limits)
+	if r.Context().Err() != nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newBestMoveResponse(board, search))
+}
+
+// searchPosition returns the position of a stored game, or of the game made
+// of moves played from fen, for the engine to search, along with the hashes of
+// the positions played before it. It fails with the HTTP status to report
+// when the position cannot be found or the game is over.
+func searchPosition(gameID, fen string, moves []string) (*chess.Board, []uint64, int, error) {
+	var board *chess.Board
+	var history []uint64
+	var result chess.GameResult
+	if gameID != "" {
+		err := games.Update(gameID, func(game *chess.Game) error {
+			board = game.Board().Clone()
+			history = game.History()
+			result = game.Status()
+			return nil
+		})
+		if err != nil {
+			return nil, nil, http.StatusNotFound, err
+		}
+	} else {
+		game, err := replayGame(fen, moves)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, err
+		}
+		board = game.Board().Clone()
+		history = game.History()
+		result = game.Status()
+	}
+
+	if result.IsOver() {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("Game is over: %s", result.Termination)
+	}
+	return board, history, http.StatusOK, nil
+}
+
+func newBestMoveResponse(board *chess.Board, result engine.SearchResult) BestMoveResponse {
+	response := BestMoveResponse{
+		Success: true,
+		Move:    result.BestMove.ToString(),
+		SAN:     board.MoveToSAN(result.BestMove),
+		Score:   result.Score,
+		Mate:    result.Mate,
+		Depth:   result.Depth,
+		Nodes:   result.Nodes,
+		Time:    result.Time.Milliseconds(),
+	}
+
+	response.PV, response.PVSAN = pvStrings(board, result.PV)
+	return response
+}
+
+func pvStrings(board *chess.Board, pv []chess.Move) ([]string, []string) {
+	moves := make([]string, len(pv))
+	sans := make([]string, len(pv))
+	position := board.Clone()
+	for i, move := range pv {
+		moves[i] = move.ToString()
+		sans[i] = position.MoveToSAN(move)
+		position.MakeMove(move)
+	}
+	return moves, sans
+}
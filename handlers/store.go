@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"chess/chess"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const DefaultGameTTL = 2 * time.Hour
+
+var ErrGameNotFound = errors.New("game not found")
+
+// session guards its game with mu. lastAccess is guarded by the store's mutex
+// instead, so that eviction never waits on a game in use.
+type session struct {
+	mu         sync.Mutex
+	game       *chess.Game
+	lastAccess time.Time
+}
+
+// GameStore holds games by id and forgets those not accessed for ttl.
+// Expired games are unreachable at once and swept from memory at most once
+// per ttl, on Create and Update.
+type GameStore struct {
+	// Clock returns the current time. It defaults to time.Now and may be
+	// replaced before the store is used.
+	Clock func() time.Time
+
+	mu        sync.Mutex
+	games     map[string]*session
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+func NewGameStore(ttl time.Duration) *GameStore {
+	return &GameStore{
+		Clock: time.Now,
+		games: make(map[string]*session),
+		ttl:   ttl,
+	}
+}
+
+func newGameID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func (s *GameStore) Create(game *chess.Game) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Clock()
+	s.sweep(now)
+
+	id := newGameID()
+	s.games[id] = &session{game: game, lastAccess: now}
+	return id
+}
+
+func (s *GameStore) Update(id string, fn func(game *chess.Game) error) error {
+	s.mu.Lock()
+	now := s.Clock()
+	s.sweep(now)
+	game, ok := s.games[id]
+	if ok && s.expired(game, now) {
+		delete(s.games, id)
+		ok = false
+	}
+	if ok {
+		game.lastAccess = now
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	return fn(game.game)
+}
+
+// Get returns a copy of the game's current position.
+func (s *GameStore) Get(id string) (chess.Board, error) {
+	var board chess.Board
+	err := s.Update(id, func(game *chess.Game) error {
+		board = *game.Board()
+		return nil
+	})
+	return board, err
+}
+
+func (s *GameStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.games, id)
+}
+
+func (s *GameStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired(s.Clock())
+	return len(s.games)
+}
+
+func (s *GameStore) expired(game *session, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(game.lastAccess) > s.ttl
+}
+
+// sweep evicts expired games if it has not done so within the last ttl.
+func (s *GameStore) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.evictExpired(now)
+}
+
+func (s *GameStore) evictExpired(now time.Time) {
+	s.lastSweep = now
+	for id, game := range s.games {
+		if s.expired(game, now) {
+			delete(s.games, id)
+		}
+	}
+}
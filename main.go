@@ -28,6 +28,10 @@ func main() {
 	apiMux.HandleFunc("/moves", handlers.HandleGetMoves)
 	apiMux.HandleFunc("/move", handlers.HandlePostMove)
 	apiMux.HandleFunc("/start", handlers.HandleStartGame)
+	apiMux.HandleFunc("/undo", handlers.HandleUndo)
+	apiMux.HandleFunc("/draw", handlers.HandleDraw)
+	apiMux.HandleFunc("/bestmove", handlers.HandleBestMove)
+	apiMux.HandleFunc("/analyze", handlers.HandleAnalyze)
 
 	fileServer := http.FileServer(http.Dir("./web"))
 	
@@ -0,0 +1,152 @@
+package pgn
+
+import (
+	"chess/chess"
+	"fmt"
+)
+
+var SevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+type Game struct {
+	Tags   []Tag
+	Root   *Node
+	Result string
+}
+
+type Node struct {
+	Parent          *Node
+	Children        []*Node
+	Move            chess.Move
+	SAN             string
+	StartingComment string
+	Comment         string
+	NAGs            []int
+	Board           chess.Board
+}
+
+func NewGame() *Game {
+	game := &Game{
+		Root:   &Node{Board: *chess.MustParseFEN(chess.StartingFEN)},
+		Result: "*",
+	}
+	for _, name := range SevenTagRoster {
+		if name == "Result" {
+			game.SetTag(name, "*")
+		} else {
+			game.SetTag(name, "?")
+		}
+	}
+	return game
+}
+
+func NewGameFromFEN(fen string) (*Game, error) {
+	board, err := chess.ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	game := NewGame()
+	game.Root.Board = *board
+	if board.Chess960 {
+		game.SetTag("Variant", "Chess960")
+	}
+	game.SetTag("SetUp", "1")
+	game.SetTag("FEN", fen)
+	return game, nil
+}
+
+func (g *Game) Tag(name string) (string, bool) {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+func (g *Game) SetTag(name, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+func (g *Game) SetResult(result string) {
+	g.Result = result
+	g.SetTag("Result", result)
+}
+
+func (g *Game) Mainline() []*Node {
+	var nodes []*Node
+	for node := g.Root; len(node.Children) > 0; {
+		node = node.Children[0]
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (g *Game) End() *Node {
+	node := g.Root
+	for len(node.Children) > 0 {
+		node = node.Children[0]
+	}
+	return node
+}
+
+func (n *Node) AddMove(move chess.Move) *Node {
+	board := n.Board
+	child := &Node{
+		Parent: n,
+		Move:   move,
+		SAN:    board.MoveToSAN(move),
+	}
+	board.MakeMove(move)
+	child.Board = board
+	n.Children = append(n.Children, child)
+	return child
+}
+
+func (n *Node) AddSAN(san string) (*Node, error) {
+	board := n.Board
+	move, err := board.ParseSAN(san)
+	if err != nil {
+		return nil, err
+	}
+	return n.AddMove(move), nil
+}
+
+func (n *Node) IsMainline() bool {
+	for node := n; node.Parent != nil; node = node.Parent {
+		if node.Parent.Children[0] != node {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Node) Ply() int {
+	ply := 0
+	for node := n; node.Parent != nil; node = node.Parent {
+		ply++
+	}
+	return ply
+}
+
+func (n *Node) String() string {
+	if n.Parent == nil {
+		return "root"
+	}
+	before := n.Parent.Board
+	if before.WhiteToMove {
+		return fmt.Sprintf("%d. %s", before.FullmoveNumber, n.SAN)
+	}
+	return fmt.Sprintf("%d... %s", before.FullmoveNumber, n.SAN)
+}
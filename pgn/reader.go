@@ -0,0 +1,375 @@
+package pgn
+
+import (
+	"bufio"
+	"chess/chess"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTag
+	tokenComment
+	tokenNAG
+	tokenOpenVariation
+	tokenCloseVariation
+	tokenResult
+	tokenMove
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	name  string
+	line  int
+}
+
+type lexer struct {
+	reader *bufio.Reader
+	line   int
+	bol    bool
+}
+
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+func newLexer(r io.Reader) *lexer {
+	return &lexer{reader: bufio.NewReader(r), line: 1, bol: true}
+}
+
+func (l *lexer) read() (byte, bool) {
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		return 0, false
+	}
+	if c == '\n' {
+		l.line++
+		l.bol = true
+	} else {
+		l.bol = false
+	}
+	return c, true
+}
+
+func (l *lexer) peek() (byte, bool) {
+	c, err := l.reader.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+	return c[0], true
+}
+
+func (l *lexer) skipLine() {
+	for {
+		c, ok := l.read()
+		if !ok || c == '\n' {
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for {
+		bol := l.bol
+		c, ok := l.read()
+		if !ok {
+			return token{kind: tokenEOF, line: l.line}, nil
+		}
+
+		switch {
+		case c == '%' && bol:
+			l.skipLine()
+		case unicode.IsSpace(rune(c)):
+		case c == ';':
+			line := l.line
+			var comment strings.Builder
+			for {
+				c, ok := l.read()
+				if !ok || c == '\n' {
+					break
+				}
+				comment.WriteByte(c)
+			}
+			return token{kind: tokenComment, value: strings.TrimSpace(comment.String()), line: line}, nil
+		case c == '{':
+			line := l.line
+			var comment strings.Builder
+			for {
+				c, ok := l.read()
+				if !ok {
+					return token{}, fmt.Errorf("line %d: unterminated comment", line)
+				}
+				if c == '}' {
+					break
+				}
+				comment.WriteByte(c)
+			}
+			return token{kind: tokenComment, value: strings.Join(strings.Fields(comment.String()), " "), line: line}, nil
+		case c == '[':
+			return l.readTag()
+		case c == '(':
+			return token{kind: tokenOpenVariation, line: l.line}, nil
+		case c == ')':
+			return token{kind: tokenCloseVariation, line: l.line}, nil
+		case c == '$':
+			line := l.line
+			digits := l.readWhile(func(c byte) bool { return c >= '0' && c <= '9' })
+			if digits == "" {
+				return token{}, fmt.Errorf("line %d: invalid NAG", line)
+			}
+			return token{kind: tokenNAG, value: digits, line: line}, nil
+		case c == '!' || c == '?':
+			line := l.line
+			suffix := string(c) + l.readWhile(func(c byte) bool { return c == '!' || c == '?' })
+			nag, ok := suffixNAGs[suffix]
+			if !ok {
+				return token{}, fmt.Errorf("line %d: invalid annotation %q", line, suffix)
+			}
+			return token{kind: tokenNAG, value: strconv.Itoa(nag), line: line}, nil
+		default:
+			line := l.line
+			word := string(c) + l.readWhile(isSymbolChar)
+			switch word {
+			case "1-0", "0-1", "1/2-1/2", "*":
+				return token{kind: tokenResult, value: word, line: line}, nil
+			}
+
+			// "e.p." may follow an en passant capture, attached or as
+			// its own word.
+			word = trimMoveNumber(strings.TrimSuffix(word, "e.p."))
+			if word == "" {
+				continue
+			}
+			return token{kind: tokenMove, value: word, line: line}, nil
+		}
+	}
+}
+
+func trimMoveNumber(word string) string {
+	number := word
+	if i := strings.LastIndexByte(word, '.'); i >= 0 {
+		number = strings.TrimRight(word[:i], ".")
+		word = word[i+1:]
+	} else {
+		word = ""
+	}
+
+	for i := 0; i < len(number); i++ {
+		if number[i] < '0' || number[i] > '9' {
+			return number + word
+		}
+	}
+	return word
+}
+
+func isSymbolChar(c byte) bool {
+	return c < 128 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("_+#=:-/.*", c) >= 0)
+}
+
+func (l *lexer) readWhile(accept func(byte) bool) string {
+	var word strings.Builder
+	for {
+		c, ok := l.peek()
+		if !ok || !accept(c) {
+			return word.String()
+		}
+		l.read()
+		word.WriteByte(c)
+	}
+}
+
+func (l *lexer) readTag() (token, error) {
+	line := l.line
+	l.readWhile(func(c byte) bool { return c == ' ' || c == '\t' })
+	name := l.readWhile(func(c byte) bool { return c != ' ' && c != '\t' && c != '"' && c != ']' && c != '\n' })
+	l.readWhile(func(c byte) bool { return c == ' ' || c == '\t' })
+
+	if c, ok := l.read(); !ok || c != '"' || name == "" {
+		return token{}, fmt.Errorf("line %d: malformed tag pair", line)
+	}
+
+	var value strings.Builder
+	for {
+		c, ok := l.read()
+		if !ok || c == '\n' {
+			return token{}, fmt.Errorf("line %d: unterminated tag value", line)
+		}
+		if c == '\\' {
+			if next, ok := l.read(); ok {
+				value.WriteByte(next)
+			}
+			continue
+		}
+		if c == '"' {
+			break
+		}
+		value.WriteByte(c)
+	}
+
+	l.readWhile(func(c byte) bool { return c == ' ' || c == '\t' })
+	if c, ok := l.read(); !ok || c != ']' {
+		return token{}, fmt.Errorf("line %d: malformed tag pair", line)
+	}
+
+	return token{kind: tokenTag, name: name, value: value.String(), line: line}, nil
+}
+
+type Reader struct {
+	lexer   *lexer
+	pending *token
+	games   int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{lexer: newLexer(r)}
+}
+
+func (r *Reader) nextToken() (token, error) {
+	if r.pending != nil {
+		tok := *r.pending
+		r.pending = nil
+		return tok, nil
+	}
+	return r.lexer.next()
+}
+
+func (r *Reader) Read() (*Game, error) {
+	game := &Game{Result: "*"}
+	node := (*Node)(nil)
+	var variations []*Node
+	var startingComment string
+	variationStart := false
+	started := false
+
+	r.games++
+
+	for {
+		tok, err := r.nextToken()
+		if err != nil {
+			return nil, fmt.Errorf("pgn: game %d: %w", r.games, err)
+		}
+
+		if tok.kind != tokenEOF && tok.kind != tokenTag && node == nil {
+			if node, err = game.setupRoot(); err != nil {
+				return nil, fmt.Errorf("pgn: game %d: %w", r.games, err)
+			}
+		}
+
+		switch tok.kind {
+		case tokenEOF:
+			if !started {
+				return nil, io.EOF
+			}
+			if node == nil {
+				if _, err := game.setupRoot(); err != nil {
+					return nil, fmt.Errorf("pgn: game %d: %w", r.games, err)
+				}
+			}
+			return game, nil
+		case tokenTag:
+			if node != nil {
+				r.pending = &tok
+				return game, nil
+			}
+			game.Tags = append(game.Tags, Tag{Name: tok.name, Value: tok.value})
+			if tok.name == "Result" {
+				game.Result = tok.value
+			}
+		case tokenComment:
+			if variationStart {
+				startingComment = joinComments(startingComment, tok.value)
+			} else {
+				node.Comment = joinComments(node.Comment, tok.value)
+			}
+		case tokenNAG:
+			nag, _ := strconv.Atoi(tok.value)
+			node.NAGs = append(node.NAGs, nag)
+		case tokenOpenVariation:
+			if node.Parent == nil {
+				return nil, fmt.Errorf("pgn: game %d: line %d: variation without a preceding move", r.games, tok.line)
+			}
+			variations = append(variations, node)
+			node = node.Parent
+			variationStart = true
+		case tokenCloseVariation:
+			if len(variations) == 0 {
+				return nil, fmt.Errorf("pgn: game %d: line %d: unbalanced ')'", r.games, tok.line)
+			}
+			node = variations[len(variations)-1]
+			variations = variations[:len(variations)-1]
+			variationStart = false
+		case tokenResult:
+			if len(variations) != 0 {
+				return nil, fmt.Errorf("pgn: game %d: line %d: unterminated variation", r.games, tok.line)
+			}
+			game.SetResult(tok.value)
+			return game, nil
+		case tokenMove:
+			child, err := node.AddSAN(tok.value)
+			if err != nil {
+				return nil, fmt.Errorf("pgn: game %d: line %d: %w", r.games, tok.line, err)
+			}
+			child.StartingComment = startingComment
+			startingComment = ""
+			variationStart = false
+			node = child
+		}
+		started = true
+	}
+}
+
+func (g *Game) setupRoot() (*Node, error) {
+	fen := chess.StartingFEN
+	if value, ok := g.Tag("FEN"); ok {
+		fen = value
+	}
+
+	board, err := chess.ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	if variant, ok := g.Tag("Variant"); ok && strings.EqualFold(variant, "Chess960") {
+		board.Chess960 = true
+	}
+
+	g.Root = &Node{Board: *board}
+	return g.Root, nil
+}
+
+func joinComments(existing, comment string) string {
+	if existing == "" {
+		return comment
+	}
+	return existing + " " + comment
+}
+
+func Parse(r io.Reader) ([]*Game, error) {
+	reader := NewReader(r)
+	var games []*Game
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, game)
+	}
+}
+
+func ParseString(s string) ([]*Game, error) {
+	return Parse(strings.NewReader(s))
+}
@@ -0,0 +1,181 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const maxLineLength = 80
+
+type movetextWriter struct {
+	words      []string
+	openPrefix string
+}
+
+func (w *movetextWriter) word(word string) {
+	w.words = append(w.words, w.openPrefix+word)
+	w.openPrefix = ""
+}
+
+func (w *movetextWriter) openVariation() {
+	w.openPrefix += "("
+}
+
+func (w *movetextWriter) closeVariation() {
+	w.words[len(w.words)-1] += ")"
+}
+
+// comment writes comment in braces, dropping any closing brace in it since
+// PGN comments cannot escape one.
+func (w *movetextWriter) comment(comment string) {
+	fields := strings.Fields(strings.ReplaceAll(comment, "}", ""))
+	if len(fields) == 0 {
+		w.word("{}")
+		return
+	}
+	fields[0] = "{" + fields[0]
+	fields[len(fields)-1] += "}"
+	for _, field := range fields {
+		w.word(field)
+	}
+}
+
+func (w *movetextWriter) move(node *Node, forceNumber bool) {
+	if node.StartingComment != "" {
+		w.comment(node.StartingComment)
+		forceNumber = true
+	}
+
+	before := node.Parent.Board
+	if before.WhiteToMove {
+		w.word(fmt.Sprintf("%d.", before.FullmoveNumber))
+	} else if forceNumber {
+		w.word(fmt.Sprintf("%d...", before.FullmoveNumber))
+	}
+
+	w.word(node.SAN)
+	for _, nag := range node.NAGs {
+		w.word(fmt.Sprintf("$%d", nag))
+	}
+	if node.Comment != "" {
+		w.comment(node.Comment)
+	}
+}
+
+func (w *movetextWriter) variation(node *Node, forceNumber bool) {
+	for len(node.Children) > 0 {
+		main := node.Children[0]
+		w.move(main, forceNumber)
+
+		for _, alternative := range node.Children[1:] {
+			w.openVariation()
+			w.move(alternative, true)
+			w.variation(alternative, false)
+			w.closeVariation()
+		}
+
+		forceNumber = len(node.Children) > 1 || main.Comment != ""
+		node = main
+	}
+}
+
+func (w *movetextWriter) lines() []string {
+	var lines []string
+	var line strings.Builder
+
+	for _, word := range w.words {
+		if line.Len() > 0 && line.Len()+1+len(word) > maxLineLength {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
+
+func (g *Game) orderedTags() []Tag {
+	tags := make([]Tag, 0, len(g.Tags)+len(SevenTagRoster))
+
+	for _, name := range SevenTagRoster {
+		value, ok := g.Tag(name)
+		switch {
+		case name == "Result":
+			value = g.Result
+		case !ok:
+			value = "?"
+		}
+		tags = append(tags, Tag{Name: name, Value: value})
+	}
+
+	for _, tag := range g.Tags {
+		isRosterTag := false
+		for _, name := range SevenTagRoster {
+			if tag.Name == name {
+				isRosterTag = true
+				break
+			}
+		}
+		if !isRosterTag {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func (g *Game) Write(w io.Writer) error {
+	var out strings.Builder
+
+	for _, tag := range g.orderedTags() {
+		fmt.Fprintf(&out, "[%s \"%s\"]\n", tag.Name, escapeTagValue(tag.Value))
+	}
+	out.WriteByte('\n')
+
+	movetext := &movetextWriter{}
+	if g.Root.Comment != "" {
+		movetext.comment(g.Root.Comment)
+	}
+	movetext.variation(g.Root, true)
+	movetext.word(g.Result)
+
+	for _, line := range movetext.lines() {
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func (g *Game) String() string {
+	var out strings.Builder
+	g.Write(&out)
+	return out.String()
+}
+
+func Write(w io.Writer, games []*Game) error {
+	for i, game := range games {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := game.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -55,16 +55,39 @@ export async function getGameState() {
   });
 }
 
-export async function makeMove(from, to, promotion = null, fen = null) {
-  const body = { from, to };
+export async function makeMove(from, to, promotion = null, moves = []) {
+  const body = { from, to, moves };
   if (promotion) {
     body.promotion = promotion;
   }
+  return apiRequest("/move", {
+    method: "POST",
+    body: JSON.stringify(body),
+  });
+}
+
+export async function undoMove(moves, fen = null) {
+  const body = { moves };
   if (fen) {
     body.fen = fen;
   }
-  return apiRequest("/move", {
+  return apiRequest("/undo", {
     method: "POST",
     body: JSON.stringify(body),
   });
 }
+
+export async function getBestMove(moves = [], depth = null, signal = null) {
+  const body = { moves };
+  if (depth) {
+    body.depth = depth;
+  }
+  const options = {
+    method: "POST",
+    body: JSON.stringify(body),
+  };
+  if (signal) {
+    options.signal = signal;
+  }
+  return apiRequest("/bestmove", options);
+}
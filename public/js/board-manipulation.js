@@ -57,3 +57,30 @@ export function resetBoard() {
     playerColorSelect.value === "white" ? COLOR.WHITE : COLOR.BLACK;
   setBoardView(selectedColor);
 }
+
+export function setBoardFromFEN(fen) {
+  if (!board) return;
+
+  const ranks = fen.split(" ")[0].split("/");
+  for (let i = 1; i <= 8; i++) {
+    let j = 1;
+    for (const c of ranks[i - 1]) {
+      if (c >= "1" && c <= "8") {
+        for (let k = 0; k < Number(c); k++, j++) {
+          document.getElementById(`${i}-${j}`).innerHTML = "";
+        }
+        continue;
+      }
+      const color = c === c.toUpperCase() ? "w" : "b";
+      const cell = document.getElementById(`${i}-${j}`);
+      cell.innerHTML = "";
+      cell.append(setPiece(`${color}${c.toUpperCase()}`, i, j));
+      j++;
+    }
+  }
+
+  const playerColorSelect = document.getElementById("player-color");
+  const selectedColor =
+    playerColorSelect.value === "white" ? COLOR.WHITE : COLOR.BLACK;
+  setBoardView(selectedColor);
+}
@@ -0,0 +1,92 @@
+import { COLOR } from "./constants.js";
+import { getBestMove, makeMove } from "./api.js";
+import {
+  setCurrentFEN,
+  getCurrentMoveColor,
+  addPlayedMove,
+  getPlayedMoves,
+  toggleMoveColor,
+} from "./state.js";
+import { setBoardFromFEN } from "./board-manipulation.js";
+import { addMoveToHistory } from "./move-history.js";
+import { updateGameStatus } from "./ui-controls.js";
+
+let pendingSearch = null;
+
+function isEngineEnabled() {
+  const engineEnabledCheckbox = document.getElementById("engine-enabled");
+  return engineEnabledCheckbox && engineEnabledCheckbox.checked;
+}
+
+export function getEngineColor() {
+  const playerColorSelect = document.getElementById("player-color");
+  return playerColorSelect && playerColorSelect.value === "black"
+    ? COLOR.WHITE
+    : COLOR.BLACK;
+}
+
+function getSearchDepth() {
+  const moveDepthSelect = document.getElementById("move-depth");
+  return moveDepthSelect ? Number(moveDepthSelect.value) : null;
+}
+
+export function isEngineThinking() {
+  return pendingSearch !== null;
+}
+
+export function isEngineTurn() {
+  return isEngineEnabled() && getCurrentMoveColor() === getEngineColor();
+}
+
+// Aborting the request also stops the search on the server.
+export function cancelEngineMove() {
+  if (pendingSearch) {
+    pendingSearch.abort();
+    pendingSearch = null;
+  }
+}
+
+export async function playEngineMove(status = null) {
+  if (pendingSearch || !isEngineTurn() || (status && status.over)) {
+    return;
+  }
+
+  const controller = new AbortController();
+  pendingSearch = controller;
+  try {
+    const result = await getBestMove(
+      getPlayedMoves(),
+      getSearchDepth(),
+      controller.signal
+    );
+    if (controller.signal.aborted) {
+      return;
+    }
+
+    const move = result.move;
+    const response = await makeMove(
+      move.slice(0, 2),
+      move.slice(2, 4),
+      move.slice(4) || null,
+      getPlayedMoves()
+    );
+    if (controller.signal.aborted || !response || !response.success) {
+      return;
+    }
+
+    setCurrentFEN(response.fen);
+    setBoardFromFEN(response.fen);
+    addMoveToHistory(response.san);
+    addPlayedMove(response.move);
+    toggleMoveColor();
+    updateGameStatus(response.status);
+  } catch (error) {
+    if (!controller.signal.aborted) {
+      console.error("Engine move failed:", error);
+    }
+  } finally {
+    if (pendingSearch === controller) {
+      pendingSearch = null;
+    }
+  }
+}
@@ -9,8 +9,9 @@ import {
   clearMovingPiece,
   toggleMoveColor,
   getCurrentMoveColor,
-  getCurrentFEN,
   setCurrentFEN,
+  addPlayedMove,
+  getPlayedMoves,
 } from "./state.js";
 import { addMoveToHistory } from "./move-history.js";
 import { setBoardView } from "./board-view.js";
@@ -19,8 +20,14 @@ import { makeMove as apiMakeMove } from "./api.js";
 import { parseSquareId, getSquareNotation } from "./notation.js";
 import { showPromotionDialog } from "./promotion.js";
 import { setPiece } from "./board-manipulation.js";
+import { updateGameStatus } from "./ui-controls.js";
+import { isEngineThinking, isEngineTurn, playEngineMove } from "./engine.js";
 
 export function handleSquareClick(square) {
+  if (isEngineThinking() || isEngineTurn()) {
+    return;
+  }
+
   if (square === getInitialSquare()) {
     setIsPieceMoving(false);
     clearMovingPiece();
@@ -73,8 +80,12 @@ async function attemptMove(fromSquare, toSquare) {
   }
 
   try {
-    const currentFEN = getCurrentFEN();
-    const response = await apiMakeMove(fromNotation, toNotation, promotion, currentFEN);
+    const response = await apiMakeMove(
+      fromNotation,
+      toNotation,
+      promotion,
+      getPlayedMoves()
+    );
 
     if (response && response.success) {
       if (response.fen) {
@@ -93,9 +104,8 @@ async function attemptMove(fromSquare, toSquare) {
         toSquareWasEmpty
       );
 
-      if (pieceImg) {
-        addMoveToHistory(fromSquare, toSquare, pieceImg);
-      }
+      addMoveToHistory(response.san);
+      addPlayedMove(response.move);
 
       toggleMoveColor();
 
@@ -103,6 +113,8 @@ async function attemptMove(fromSquare, toSquare) {
       const selectedColor =
         playerColorSelect.value === "white" ? COLOR.WHITE : COLOR.BLACK;
       setBoardView(selectedColor);
+      updateGameStatus(response.status);
+      playEngineMove(response.status);
     }
   } catch (error) {
     console.error("Move failed:", error);
@@ -3,29 +3,18 @@ import {
   getCurrentMoveColor,
   getMoveNumber,
   incrementMoveNumber,
+  decrementMoveNumber,
   addToMoveHistory,
+  popMoveHistory,
 } from "./state.js";
-import {
-  getSquareNotation,
-  parseSquareId,
-  getPieceNotation,
-} from "./notation.js";
-
-export function addMoveToHistory(fromSquare, toSquare, pieceImg) {
-  const fromCoords = parseSquareId(fromSquare.id);
-  const toCoords = parseSquareId(toSquare.id);
 
-  if (!fromCoords || !toCoords) {
+export function addMoveToHistory(moveNotation) {
+  if (!moveNotation) {
     return;
   }
 
-  const fromNotation = getSquareNotation(fromCoords.row, fromCoords.col);
-  const toNotation = getSquareNotation(toCoords.row, toCoords.col);
-  const pieceNotation = getPieceNotation(pieceImg);
   const isWhiteMove = getCurrentMoveColor() === COLOR.WHITE;
 
-  const moveNotation = `${pieceNotation}${fromNotation}-${toNotation}`;
-
   if (isWhiteMove) {
     const moveItem = document.createElement("div");
     moveItem.className = "move-item";
@@ -70,3 +59,26 @@ export function clearMoveHistory() {
     movesList.innerHTML = "";
   }
 }
+
+export function removeLastMoveFromHistory() {
+  const lastMove = popMoveHistory();
+  const movesList = document.getElementById("moves-list");
+  if (!lastMove || !movesList || !movesList.lastElementChild) {
+    return;
+  }
+
+  const lastItem = movesList.lastElementChild;
+  if (lastMove.color === COLOR.WHITE) {
+    lastItem.remove();
+    decrementMoveNumber();
+    return;
+  }
+
+  const blackMove = lastItem.querySelector(".move-black");
+  if (blackMove) {
+    blackMove.remove();
+  }
+  if (!lastItem.querySelector(".move-white")) {
+    lastItem.remove();
+  }
+}
@@ -4,27 +4,6 @@ export function getSquareNotation(row, col) {
   return files[col - 1] + ranks[row - 1];
 }
 
-export function getPieceNotation(pieceImg) {
-  if (!pieceImg) return "";
-  const src = pieceImg.getAttribute("src") || "";
-  const pieceMatch = src.match(/\/([wb][KQRNBP])\.svg/);
-  if (!pieceMatch) return "";
-
-  const piece = pieceMatch[1];
-  const type = piece[1];
-
-  const pieceMap = {
-    K: "K",
-    Q: "Q",
-    R: "R",
-    B: "B",
-    N: "N",
-    P: "",
-  };
-
-  return pieceMap[type] || "";
-}
-
 export function parseSquareId(squareId) {
   const parts = squareId.split("-");
   if (parts.length !== 2) {
@@ -9,6 +9,7 @@ export let moveHistory = [];
 export let moveNumber = 1;
 export let playerViewColor = COLOR.WHITE;
 export let currentFEN = null;
+export let playedMoves = [];
 
 export function setBoard(boardElement) {
   board = boardElement;
@@ -17,6 +18,7 @@ export function setBoard(boardElement) {
 export function resetGameState() {
   currentMoveColor = COLOR.WHITE;
   moveHistory = [];
+  playedMoves = [];
   moveNumber = 1;
   isPieceMoving = false;
   movingPiece = null;
@@ -88,10 +90,30 @@ export function clearMoveHistory() {
   moveHistory = [];
 }
 
+export function popMoveHistory() {
+  return moveHistory.pop();
+}
+
+export function addPlayedMove(move) {
+  playedMoves.push(move);
+}
+
+export function getPlayedMoves() {
+  return playedMoves;
+}
+
+export function popPlayedMove() {
+  return playedMoves.pop();
+}
+
 export function incrementMoveNumber() {
   moveNumber++;
 }
 
+export function decrementMoveNumber() {
+  moveNumber--;
+}
+
 export function getMoveNumber() {
   return moveNumber;
 }
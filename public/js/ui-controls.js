@@ -1,9 +1,19 @@
 import { COLOR } from "./constants.js";
 import { setBoardView } from "./board-view.js";
-import { resetBoard } from "./board-manipulation.js";
-import { clearMoveHistory } from "./move-history.js";
-import { startGame } from "./api.js";
-import { setCurrentFEN, resetFEN } from "./state.js";
+import { resetBoard, setBoardFromFEN } from "./board-manipulation.js";
+import {
+  clearMoveHistory,
+  removeLastMoveFromHistory,
+} from "./move-history.js";
+import { startGame, undoMove } from "./api.js";
+import { cancelEngineMove, isEngineTurn, playEngineMove } from "./engine.js";
+import {
+  setCurrentFEN,
+  resetFEN,
+  getPlayedMoves,
+  popPlayedMove,
+  toggleMoveColor,
+} from "./state.js";
 
 export function initializeUIControls() {
   const playerColorSelect = document.getElementById("player-color");
@@ -11,6 +21,7 @@ export function initializeUIControls() {
     playerColorSelect.addEventListener("change", function () {
       const selectedColor = this.value === "white" ? COLOR.WHITE : COLOR.BLACK;
       setBoardView(selectedColor);
+      playEngineMove();
     });
     const selectedColor =
       playerColorSelect.value === "white" ? COLOR.WHITE : COLOR.BLACK;
@@ -20,6 +31,7 @@ export function initializeUIControls() {
   const resetBoardBtn = document.getElementById("reset-board-btn");
   if (resetBoardBtn) {
     resetBoardBtn.addEventListener("click", function () {
+      cancelEngineMove();
       resetBoard();
       clearMoveHistory();
     });
@@ -28,6 +40,7 @@ export function initializeUIControls() {
   const newGameBtn = document.getElementById("new-game-btn");
   if (newGameBtn) {
     newGameBtn.addEventListener("click", async function () {
+      cancelEngineMove();
       try {
         const response = await startGame();
         if (response && response.fen) {
@@ -35,12 +48,26 @@ export function initializeUIControls() {
         }
         resetBoard();
         clearMoveHistory();
+        updateGameStatus(response && response.status);
+        playEngineMove();
       } catch (error) {
         console.error("Failed to start game:", error);
       }
     });
   }
 
+  const undoMoveBtn = document.getElementById("undo-move-btn");
+  if (undoMoveBtn) {
+    undoMoveBtn.addEventListener("click", async function () {
+      cancelEngineMove();
+      // Against the engine, take back its reply along with the player's move.
+      if ((await takeBackMove()) && isEngineTurn()) {
+        await takeBackMove();
+      }
+      playEngineMove();
+    });
+  }
+
   const moveDepthSelect = document.getElementById("move-depth");
   if (moveDepthSelect) {
     moveDepthSelect.addEventListener("change", function () {
@@ -51,7 +78,53 @@ export function initializeUIControls() {
   const engineEnabledCheckbox = document.getElementById("engine-enabled");
   if (engineEnabledCheckbox) {
     engineEnabledCheckbox.addEventListener("change", function () {
-      console.log("Engine enabled:", this.checked);
+      if (this.checked) {
+        playEngineMove();
+      } else {
+        cancelEngineMove();
+      }
     });
   }
 }
+
+async function takeBackMove() {
+  const moves = getPlayedMoves();
+  if (moves.length === 0) {
+    return false;
+  }
+  try {
+    const response = await undoMove(moves);
+    if (response && response.success) {
+      popPlayedMove();
+      setCurrentFEN(response.fen);
+      setBoardFromFEN(response.fen);
+      removeLastMoveFromHistory();
+      toggleMoveColor();
+      updateGameStatus(response.status);
+      return true;
+    }
+  } catch (error) {
+    console.error("Failed to take back move:", error);
+  }
+  return false;
+}
+
+export function updateGameStatus(status) {
+  const statusElement = document.getElementById("game-status");
+  if (!statusElement) {
+    return;
+  }
+
+  if (!status || !status.over) {
+    statusElement.textContent =
+      status && status.claimable ? `Draw can be claimed by ${status.claimable}` : "";
+    return;
+  }
+
+  if (status.winner) {
+    const winner = status.winner === "white" ? "White" : "Black";
+    statusElement.textContent = `${status.result} - ${winner} wins by ${status.termination}`;
+  } else {
+    statusElement.textContent = `${status.result} - Draw by ${status.termination}`;
+  }
+}
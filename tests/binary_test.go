@@ -0,0 +1,178 @@
+package main
+
+import (
+	"chess/chess"
+	"encoding"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*chess.Board)(nil)
+	_ encoding.BinaryUnmarshaler = (*chess.Board)(nil)
+	_ encoding.BinaryAppender    = (*chess.Board)(nil)
+)
+
+func TestBoardClone(t *testing.T) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	clone := board.Clone()
+	if !clone.Equal(board) {
+		t.Fatalf("clone differs from original: %s", clone.ToFEN())
+	}
+
+	move := chess.GenerateAllLegalMoves(clone)[0]
+	clone.MakeMove(move)
+	if board.Equal(clone) {
+		t.Errorf("making %s on the clone changed the original", move.ToString())
+	}
+	if !board.Equal(chess.NewBoardFromFEN(kiwipeteFEN)) {
+		t.Errorf("original modified: %s", board.ToFEN())
+	}
+}
+
+func TestBoardEqual(t *testing.T) {
+	tests := map[string]struct {
+		a, b     string
+		equal    bool
+		position bool
+	}{
+		"identical": {
+			a:        chess.StartingFEN,
+			b:        chess.StartingFEN,
+			equal:    true,
+			position: true,
+		},
+		"different counters": {
+			a:        "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			b:        "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 12 40",
+			position: true,
+		},
+		"different castling rights": {
+			a: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			b: "r3k2r/8/8/8/8/8/8/R3K2R w Kkq - 0 1",
+		},
+		"different side to move": {
+			a: "4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+			b: "4k3/8/8/8/8/8/8/4K3 b - - 0 1",
+		},
+		"different en passant square": {
+			a: "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1",
+			b: "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1",
+		},
+		"different pieces": {
+			a: "4k3/8/8/8/8/8/8/4K2R w - - 0 1",
+			b: "4k3/8/8/8/8/8/8/4K2Q w - - 0 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, b := chess.NewBoardFromFEN(test.a), chess.NewBoardFromFEN(test.b)
+			if got := a.Equal(b); got != test.equal {
+				t.Errorf("Equal: expected %v, got %v", test.equal, got)
+			}
+			if got := a.EqualPosition(b); got != test.position {
+				t.Errorf("EqualPosition: expected %v, got %v", test.position, got)
+			}
+		})
+	}
+}
+
+func TestBoardBinaryRoundTrip(t *testing.T) {
+	positions, err := readPerftSuite("testdata/perftsuite.epd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chess960, err := readPerftSuite("testdata/chess960.epd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fens := []string{"4k3/8/8/8/4P3/8/8/4K3 b - e3 17 93"}
+	for _, position := range append(positions, chess960...) {
+		fens = append(fens, position.fen)
+	}
+
+	for _, fen := range fens {
+		board := chess.NewBoardFromFEN(fen)
+		for ply := 0; ply < 20; ply++ {
+			data, err := board.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded chess.Board
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("%s: %v", board.ToFEN(), err)
+			}
+			if !decoded.Equal(board) || decoded.Hash != board.Hash {
+				t.Fatalf("round trip of %s gave %s", board.ToShredderFEN(), decoded.ToShredderFEN())
+			}
+
+			moves := chess.GenerateAllLegalMoves(board)
+			if len(moves) == 0 {
+				break
+			}
+			board.MakeMove(moves[(ply*7)%len(moves)])
+		}
+	}
+}
+
+func TestBoardBinarySize(t *testing.T) {
+	data, err := chess.NewBoardFromFEN(chess.StartingFEN).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 27 {
+		t.Errorf("expected the starting position to take 27 bytes, got %d", len(data))
+	}
+}
+
+func TestBoardUnmarshalBinaryErrors(t *testing.T) {
+	valid, err := chess.NewBoardFromFEN(kiwipeteFEN).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	noKings, err := chess.NewBoardFromFEN(chess.StartingFEN).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	noKings[8+2] = 0x99
+
+	tests := map[string][]byte{
+		"empty":          nil,
+		"truncated":      valid[:len(valid)-2],
+		"trailing bytes": append(append([]byte(nil), valid...), 0),
+		"invalid piece":  append([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0x7}, valid[8:]...),
+		"missing king":   noKings,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(chess.StartingFEN)
+			if err := board.UnmarshalBinary(data); err == nil {
+				t.Errorf("expected error for %x", data)
+			}
+			if !board.Equal(chess.NewBoardFromFEN(chess.StartingFEN)) {
+				t.Errorf("board modified by failed unmarshal: %s", board.ToFEN())
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalBinary(b *testing.B) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	data := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _ = board.AppendBinary(data[:0])
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	data, _ := chess.NewBoardFromFEN(kiwipeteFEN).MarshalBinary()
+	var board chess.Board
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := board.UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func TestMoveCounters(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		move     string
+		halfmove int
+		fullmove int
+	}{
+		"white pawn push": {
+			fen:      chess.StartingFEN,
+			move:     "e2e4",
+			halfmove: 0,
+			fullmove: 1,
+		},
+		"white quiet move": {
+			fen:      "4k3/8/8/8/8/8/8/R3K3 w - - 10 20",
+			move:     "a1a2",
+			halfmove: 11,
+			fullmove: 20,
+		},
+		"black quiet move": {
+			fen:      "4k3/8/8/8/8/8/8/R3K3 b - - 10 20",
+			move:     "e8d8",
+			halfmove: 11,
+			fullmove: 21,
+		},
+		"white capture": {
+			fen:      "4k3/8/8/8/8/8/r7/R3K3 w - - 10 20",
+			move:     "a1a2",
+			halfmove: 0,
+			fullmove: 20,
+		},
+		"black capture": {
+			fen:      "4k3/8/8/8/8/8/r7/R3K3 b - - 10 20",
+			move:     "a2a1",
+			halfmove: 0,
+			fullmove: 21,
+		},
+		"black pawn push": {
+			fen:      "4k3/4p3/8/8/8/8/8/R3K3 b - - 7 5",
+			move:     "e7e5",
+			halfmove: 0,
+			fullmove: 6,
+		},
+		"en passant": {
+			fen:      "4k3/8/8/3Pp3/8/8/8/4K3 w - e6 3 30",
+			move:     "d5e6",
+			halfmove: 0,
+			fullmove: 30,
+		},
+		"promotion": {
+			fen:      "4k3/8/8/8/8/8/p7/4K3 b - - 12 40",
+			move:     "a2a1q",
+			halfmove: 0,
+			fullmove: 41,
+		},
+		"castling": {
+			fen:      "r3k3/8/8/8/8/8/8/4K3 b q - 3 9",
+			move:     "e8c8",
+			halfmove: 4,
+			fullmove: 10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(test.fen)
+			move, err := board.ValidateMove(test.move[0:2], test.move[2:4], test.move[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			undo := board.MakeMove(move)
+			if board.HalfmoveClock != test.halfmove || board.FullmoveNumber != test.fullmove {
+				t.Errorf("expected counters %d %d, got %d %d", test.halfmove, test.fullmove, board.HalfmoveClock, board.FullmoveNumber)
+			}
+
+			board.UndoMove(move, undo)
+			if board.ToFEN() != test.fen {
+				t.Errorf("undo restored %q, expected %q", board.ToFEN(), test.fen)
+			}
+		})
+	}
+}
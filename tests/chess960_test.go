@@ -0,0 +1,201 @@
+package main
+
+import (
+	"chess/chess"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+func TestChess960PerftSuite(t *testing.T) {
+	runPerftSuite(t, "testdata/chess960.epd")
+}
+
+func TestChess960StartingFEN(t *testing.T) {
+	tests := map[string]struct {
+		index int
+		fen   string
+	}{
+		"first": {
+			index: 0,
+			fen:   "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1",
+		},
+		"standard": {
+			index: 518,
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+		},
+		"last": {
+			index: 959,
+			fen:   "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w CAca - 0 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fen, err := chess.Chess960StartingFEN(test.index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fen != test.fen {
+				t.Errorf("position %d: expected %q, got %q", test.index, test.fen, fen)
+			}
+		})
+	}
+
+	for _, index := range []int{-1, 960} {
+		if _, err := chess.Chess960StartingFEN(index); err == nil {
+			t.Errorf("expected error for position %d", index)
+		}
+	}
+}
+
+func TestChess960StartingPositionsValid(t *testing.T) {
+	const lightSquares = uint64(0x55aa55aa55aa55aa)
+	seen := make(map[string]bool)
+
+	for index := 0; index < 960; index++ {
+		fen, err := chess.Chess960StartingFEN(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		board, err := chess.ParseFEN(fen)
+		if err != nil {
+			t.Fatalf("position %d: %v", index, err)
+		}
+
+		backRank := strings.Split(fen, "/")[0]
+		if seen[backRank] {
+			t.Errorf("position %d: %s generated twice", index, backRank)
+		}
+		seen[backRank] = true
+
+		if bits.OnesCount64(board.WhiteBishops&lightSquares) != 1 {
+			t.Errorf("position %d: %s has both bishops on one colour", index, backRank)
+		}
+		king := bits.TrailingZeros64(board.WhiteKing)
+		if board.CastlingRookFiles[chess.White][chess.QueenSide] >= king ||
+			board.CastlingRookFiles[chess.White][chess.KingSide] <= king {
+			t.Errorf("position %d: %s does not have the king between the rooks", index, backRank)
+		}
+		if !board.Chess960 {
+			t.Errorf("position %d: %s did not parse as Chess960", index, fen)
+		}
+	}
+}
+
+func TestChess960CastlingFEN(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		chess960 bool
+		xfen     string
+		shredder string
+	}{
+		"standard": {
+			fen:      chess.StartingFEN,
+			xfen:     "KQkq",
+			shredder: "HAha",
+		},
+		"shredder": {
+			fen:      "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+			chess960: true,
+			xfen:     "KQkq",
+			shredder: "HFhf",
+		},
+		"x-fen": {
+			fen:      "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9",
+			chess960: true,
+			xfen:     "KQkq",
+			shredder: "HFhf",
+		},
+		"inner rook": {
+			fen:      "1k6/8/8/8/8/8/8/1KR4R w C - 0 1",
+			chess960: true,
+			xfen:     "C",
+			shredder: "C",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board, err := chess.ParseFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if board.Chess960 != test.chess960 {
+				t.Errorf("expected Chess960 %v, got %v", test.chess960, board.Chess960)
+			}
+			if castling := strings.Fields(board.ToFEN())[2]; castling != test.xfen {
+				t.Errorf("ToFEN: expected castling %q, got %q", test.xfen, castling)
+			}
+			if castling := strings.Fields(board.ToShredderFEN())[2]; castling != test.shredder {
+				t.Errorf("ToShredderFEN: expected castling %q, got %q", test.shredder, castling)
+			}
+		})
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := map[string]struct {
+		fen   string
+		move  string
+		after string
+	}{
+		"king takes adjacent rook": {
+			fen:   "4k3/8/8/8/8/8/8/5KR1 w G - 0 1",
+			move:  "f1g1",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+		"king stays put": {
+			fen:   "4k3/8/8/8/8/8/8/2R3KR w H - 0 1",
+			move:  "g1h1",
+			after: "4k3/8/8/8/8/8/8/2R2RK1 b - - 1 1",
+		},
+		"queenside across the board": {
+			fen:   "r5kr/8/8/8/8/8/8/4K3 b a - 0 1",
+			move:  "g8a8",
+			after: "2kr3r/8/8/8/8/8/8/4K3 w - - 1 2",
+		},
+		"standard encoding": {
+			fen:   "4k3/8/8/8/8/8/8/4K2R w K - 0 1",
+			move:  "e1g1",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(test.fen)
+			before := *board
+
+			move, err := board.ValidateMove(test.move[:2], test.move[2:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if flag := move.Flag(); flag != chess.FlagKingCastle && flag != chess.FlagQueenCastle {
+				t.Fatalf("%s: expected a castling move, got flag %d", test.move, flag)
+			}
+
+			undoInfo := board.MakeMove(move)
+			if fen := board.ToFEN(); fen != test.after {
+				t.Errorf("after %s: expected %q, got %q", test.move, test.after, fen)
+			}
+			if board.Hash != board.ComputeHash() {
+				t.Errorf("after %s: incremental hash differs from recomputed hash", test.move)
+			}
+
+			board.UndoMove(move, undoInfo)
+			if *board != before {
+				t.Errorf("undo %s: expected %q, got %q", test.move, before.ToFEN(), board.ToFEN())
+			}
+		})
+	}
+}
+
+func TestChess960CastlingRookShieldsKing(t *testing.T) {
+	board := chess.NewBoardFromFEN("4k3/8/8/8/8/8/8/rRK5 w B - 0 1")
+	for _, move := range chess.GenerateAllLegalMoves(board) {
+		if move.Flag() == chess.FlagQueenCastle {
+			t.Errorf("%s castles into the enemy rook's line once the b1 rook leaves", move.ToString())
+		}
+	}
+}
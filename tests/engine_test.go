@@ -0,0 +1,269 @@
+package main
+
+import (
+	"chess/chess"
+	"chess/engine"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSearchFindsMate(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		depth    int
+		bestMove string
+		mate     int
+		mated    bool
+	}{
+		"scholars mate": {
+			fen:      "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4",
+			depth:    3,
+			bestMove: "h5f7",
+			mate:     1,
+		},
+		"back rank mate": {
+			fen:      "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1",
+			depth:    3,
+			bestMove: "d1d8",
+			mate:     1,
+		},
+		"already checkmated": {
+			fen:   "6k1/5ppp/8/8/8/8/r4PPP/r5K1 w - - 0 1",
+			depth: 3,
+			mated: true,
+		},
+		"ladder mate in two": {
+			fen:   "7k/8/8/8/8/8/R7/1R5K w - - 0 1",
+			depth: 4,
+			mate:  2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := engine.Search(chess.NewBoardFromFEN(test.fen), engine.Limits{Depth: test.depth})
+			if test.mated {
+				if result.BestMove != 0 || result.Score != -engine.MateScore {
+					t.Errorf("expected no move and mated score, got %s %d", result.BestMove.ToString(), result.Score)
+				}
+				return
+			}
+			if test.bestMove != "" && result.BestMove.ToString() != test.bestMove {
+				t.Errorf("expected %s, got %s", test.bestMove, result.BestMove.ToString())
+			}
+			if result.Mate != test.mate {
+				t.Errorf("expected mate in %d, got %d (score %d)", test.mate, result.Mate, result.Score)
+			}
+			if len(result.PV) == 0 || result.PV[0] != result.BestMove {
+				t.Errorf("principal variation does not start with best move")
+			}
+		})
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	board := chess.NewBoardFromFEN(chess.StartingFEN)
+
+	result := engine.Search(board, engine.Limits{Depth: 3})
+	if result.Depth != 3 {
+		t.Errorf("expected depth 3, got %d", result.Depth)
+	}
+
+	result = engine.Search(board, engine.Limits{Nodes: 5000})
+	if result.Nodes > 5000+engine.MaxPly {
+		t.Errorf("expected at most about 5000 nodes, got %d", result.Nodes)
+	}
+	if result.BestMove == 0 {
+		t.Errorf("expected a best move under a node limit")
+	}
+
+	if board.ToFEN() != chess.StartingFEN {
+		t.Errorf("search modified the caller's board: %s", board.ToFEN())
+	}
+}
+
+func TestSearchRepetitionBeforeRoot(t *testing.T) {
+	// Black, a queen down, can only hope to repeat the position with Kh8.
+	board := chess.NewBoardFromFEN("6k1/8/8/8/8/8/8/3Q2K1 b - - 3 2")
+	result := engine.Search(board, engine.Limits{Depth: 3})
+	if result.Score >= 0 {
+		t.Fatalf("expected black to be lost without history, got %d", result.Score)
+	}
+
+	history := []uint64{chess.NewBoardFromFEN("7k/8/8/8/8/8/8/3Q2K1 w - - 0 1").Hash, 1, 2}
+	result = engine.Search(board, engine.Limits{Depth: 3, History: history})
+	if result.BestMove.ToString() != "g8h8" || result.Score != 0 {
+		t.Errorf("expected g8h8 drawing by repetition, got %s scoring %d", result.BestMove.ToString(), result.Score)
+	}
+}
+
+func TestSearchThreads(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		depth    int
+		bestMove string
+		mate     int
+	}{
+		"back rank mate": {
+			fen:      "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1",
+			depth:    3,
+			bestMove: "d1d8",
+			mate:     1,
+		},
+		"ladder mate in two": {
+			fen:   "7k/8/8/8/8/8/R7/1R5K w - - 0 1",
+			depth: 4,
+			mate:  2,
+		},
+		"kiwipete": {
+			fen:   kiwipeteFEN,
+			depth: 4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := engine.New()
+			e.Threads = 4
+			board := chess.NewBoardFromFEN(test.fen)
+			result := e.Search(board, engine.Limits{Depth: test.depth})
+
+			if test.mate == 0 && result.Depth != test.depth {
+				t.Errorf("expected depth %d, got %d", test.depth, result.Depth)
+			}
+			if !slices.Contains(chess.GenerateAllLegalMoves(board), result.BestMove) {
+				t.Fatalf("best move %s is not legal", result.BestMove.ToString())
+			}
+			if test.bestMove != "" && result.BestMove.ToString() != test.bestMove {
+				t.Errorf("expected %s, got %s", test.bestMove, result.BestMove.ToString())
+			}
+			if result.Mate != test.mate {
+				t.Errorf("expected mate in %d, got %d", test.mate, result.Mate)
+			}
+			if board.ToFEN() != test.fen {
+				t.Errorf("search modified the caller's board: %s", board.ToFEN())
+			}
+		})
+	}
+}
+
+func TestSearchSingleThreadDeterministic(t *testing.T) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	first := engine.Search(board, engine.Limits{Depth: 4})
+	second := engine.Search(board, engine.Limits{Depth: 4})
+	if first.Nodes != second.Nodes || first.Score != second.Score || !slices.Equal(first.PV, second.PV) {
+		t.Errorf("searches differ: %d nodes %d cp %v, then %d nodes %d cp %v",
+			first.Nodes, first.Score, first.PV, second.Nodes, second.Score, second.PV)
+	}
+}
+
+func TestSearchThreadsNodeLimit(t *testing.T) {
+	e := engine.New()
+	e.Threads = 4
+	result := e.Search(chess.NewBoardFromFEN(chess.StartingFEN), engine.Limits{Nodes: 20000})
+	if result.Nodes > 20000+4*2048 {
+		t.Errorf("expected about 20000 nodes across threads, got %d", result.Nodes)
+	}
+	if result.BestMove == 0 {
+		t.Errorf("expected a best move under a node limit")
+	}
+}
+
+func TestSearchContextCancel(t *testing.T) {
+	for _, threads := range []int{1, 4} {
+		e := engine.New()
+		e.Threads = threads
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		result := e.SearchContext(ctx, chess.NewBoardFromFEN(kiwipeteFEN), engine.Limits{Infinite: true})
+		cancel()
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%d threads: search took %v after cancellation", threads, elapsed)
+		}
+		if result.BestMove == 0 {
+			t.Errorf("%d threads: expected a best move", threads)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	result := engine.New().SearchContext(ctx, chess.NewBoardFromFEN(chess.StartingFEN), engine.Limits{Depth: 20})
+	if elapsed := time.Since(start); elapsed > time.Second || result.BestMove == 0 {
+		t.Errorf("search with a cancelled context took %v and returned %s", elapsed, result.BestMove.ToString())
+	}
+}
+
+func TestSearchMultiPV(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		depth    int
+		multiPV  int
+		lines    int
+		bestMove string
+	}{
+		"starting position": {
+			fen:     chess.StartingFEN,
+			depth:   3,
+			multiPV: 4,
+			lines:   4,
+		},
+		"fewer legal moves than lines": {
+			fen:     "7k/8/8/8/8/8/6q1/7K w - - 0 1",
+			depth:   3,
+			multiPV: 5,
+			lines:   1,
+		},
+		"mate first": {
+			fen:      "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1",
+			depth:    3,
+			multiPV:  3,
+			lines:    3,
+			bestMove: "d1d8",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := engine.Search(chess.NewBoardFromFEN(test.fen), engine.Limits{Depth: test.depth, MultiPV: test.multiPV})
+			if len(result.Lines) != test.lines {
+				t.Fatalf("expected %d lines, got %d", test.lines, len(result.Lines))
+			}
+
+			best := result.Lines[0]
+			if best.Score != result.Score || best.Mate != result.Mate || !slices.Equal(best.PV, result.PV) || best.PV[0] != result.BestMove {
+				t.Errorf("first line %+v does not match the result", best)
+			}
+			if test.bestMove != "" && result.BestMove.ToString() != test.bestMove {
+				t.Errorf("expected %s, got %s", test.bestMove, result.BestMove.ToString())
+			}
+
+			seen := make(map[chess.Move]bool)
+			for i, line := range result.Lines {
+				if len(line.PV) == 0 || seen[line.PV[0]] {
+					t.Errorf("line %d: empty or repeated first move %v", i+1, line.PV)
+					continue
+				}
+				seen[line.PV[0]] = true
+				if i > 0 && line.Score > result.Lines[i-1].Score {
+					t.Errorf("line %d scores %d, above line %d at %d", i+1, line.Score, i, result.Lines[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchMultiPVMatchesSinglePV(t *testing.T) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	single := engine.Search(board, engine.Limits{Depth: 3})
+	multi := engine.Search(board, engine.Limits{Depth: 3, MultiPV: 3})
+	if multi.Lines[0].Score != single.Score {
+		t.Errorf("best line scores %d with MultiPV, %d without", multi.Lines[0].Score, single.Score)
+	}
+	if len(single.Lines) != 1 || !slices.Equal(single.Lines[0].PV, single.PV) {
+		t.Errorf("single PV search should report its one line, got %+v", single.Lines)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"chess/chess"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const shortPerftDepth = 4
+
+type perftExpectation struct {
+	depth int
+	nodes uint64
+}
+
+type epdPosition struct {
+	line     int
+	fen      string
+	expected []perftExpectation
+}
+
+func readPerftSuite(path string) ([]epdPosition, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var positions []epdPosition
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		position := epdPosition{line: line, fen: strings.TrimSpace(fields[0])}
+		for _, operation := range fields[1:] {
+			parts := strings.Fields(operation)
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], "D") {
+				return nil, fmt.Errorf("line %d: malformed operation %q", line, operation)
+			}
+			depth, err := strconv.Atoi(parts[0][1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad depth %q", line, parts[0])
+			}
+			nodes, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad node count %q", line, parts[1])
+			}
+			position.expected = append(position.expected, perftExpectation{depth: depth, nodes: nodes})
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, scanner.Err()
+}
+
+func referencePerft(board *chess.Board, depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var nodes uint64
+	for _, move := range trialLegalMoves(board) {
+		undoInfo := board.MakeMove(move)
+		nodes += referencePerft(board, depth-1)
+		board.UndoMove(move, undoInfo)
+	}
+	return nodes
+}
+
+func firstDivideMismatch(board *chess.Board, depth int) string {
+	divide := chess.Divide(board, depth)
+	counts := make(map[chess.Move]uint64, len(divide.Entries))
+	for _, entry := range divide.Entries {
+		counts[entry.Move] = entry.Nodes
+	}
+
+	for _, move := range trialLegalMoves(board) {
+		undoInfo := board.MakeMove(move)
+		want := referencePerft(board, depth-1)
+		board.UndoMove(move, undoInfo)
+
+		got, ok := counts[move]
+		if !ok {
+			return fmt.Sprintf("%s is missing from divide (reference %d)", move.ToString(), want)
+		}
+		if got != want {
+			return fmt.Sprintf("%s: divide %d, reference %d", move.ToString(), got, want)
+		}
+		delete(counts, move)
+	}
+	for move := range counts {
+		return fmt.Sprintf("%s is generated but illegal", move.ToString())
+	}
+	return "no root move differs from the make/unmake reference"
+}
+
+func TestPerftSuite(t *testing.T) {
+	runPerftSuite(t, "testdata/perftsuite.epd")
+}
+
+func runPerftSuite(t *testing.T, path string) {
+	positions, err := readPerftSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, position := range positions {
+		position := position
+		t.Run(fmt.Sprintf("line%03d", position.line), func(t *testing.T) {
+			board, err := chess.ParseFEN(position.fen)
+			if err != nil {
+				t.Fatalf("%s: %v", position.fen, err)
+			}
+
+			for _, expected := range position.expected {
+				t.Run(fmt.Sprintf("D%d", expected.depth), func(t *testing.T) {
+					if testing.Short() && expected.depth > shortPerftDepth {
+						t.Skipf("depth %d skipped in short mode", expected.depth)
+					}
+
+					nodes := chess.PerftParallel(board, expected.depth, 0)
+					if nodes != expected.nodes {
+						t.Errorf("%s: expected %d nodes, got %d; %s", position.fen, expected.nodes, nodes,
+							firstDivideMismatch(board, expected.depth))
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"chess/chess"
+	"chess/engine"
+	"strings"
+	"testing"
+)
+
+func mirrorFEN(fen string) string {
+	parts := strings.Fields(fen)
+
+	ranks := strings.Split(parts[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	parts[0] = swapCase(strings.Join(ranks, "/"))
+
+	if parts[1] == "w" {
+		parts[1] = "b"
+	} else {
+		parts[1] = "w"
+	}
+
+	if parts[2] != "-" {
+		castling := swapCase(parts[2])
+		var ordered strings.Builder
+		for _, right := range "KQkq" {
+			if strings.ContainsRune(castling, right) {
+				ordered.WriteRune(right)
+			}
+		}
+		parts[2] = ordered.String()
+	}
+
+	if parts[3] != "-" {
+		rank := '1' + '8' - rune(parts[3][1])
+		parts[3] = string(parts[3][0]) + string(rank)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return r
+	}, s)
+}
+
+var evaluationPositions = []string{
+	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+	"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+	"8/5pk1/6p1/8/3P4/8/5PPP/6K1 b - - 0 40",
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	for _, fen := range evaluationPositions {
+		mirrored := mirrorFEN(fen)
+
+		score := engine.Evaluate(chess.NewBoardFromFEN(fen))
+		mirroredScore := engine.Evaluate(chess.NewBoardFromFEN(mirrored))
+
+		if score != -mirroredScore {
+			t.Errorf("%s evaluates to %d but mirrored %s evaluates to %d", fen, score, mirrored, mirroredScore)
+		}
+	}
+}
+
+func TestEvaluateStartingPositionIsBalanced(t *testing.T) {
+	if score := engine.Evaluate(chess.NewBoardFromFEN(chess.StartingFEN)); score != 0 {
+		t.Errorf("expected starting position to evaluate to 0, got %d", score)
+	}
+}
+
+func TestEvaluateMaterialAdvantage(t *testing.T) {
+	tests := map[string]struct {
+		fen      string
+		positive bool
+	}{
+		"white up a queen": {
+			fen:      "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			positive: true,
+		},
+		"black up a rook": {
+			fen:      "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1",
+			positive: false,
+		},
+		"white passed pawn in endgame": {
+			fen:      "6k1/8/8/3P4/8/8/8/6K1 w - - 0 1",
+			positive: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			score := engine.Evaluate(chess.NewBoardFromFEN(test.fen))
+			if (score > 0) != test.positive {
+				t.Errorf("unexpected evaluation %d", score)
+			}
+		})
+	}
+}
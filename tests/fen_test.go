@@ -0,0 +1,94 @@
+package main
+
+import (
+	"chess/chess"
+	"strings"
+	"testing"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 b - - 37 84",
+	} {
+		board, err := chess.ParseFEN(fen)
+		if err != nil {
+			t.Errorf("ParseFEN(%q): %v", fen, err)
+			continue
+		}
+		if got := board.ToFEN(); got != fen {
+			t.Errorf("expected %q, got %q", fen, got)
+		}
+	}
+}
+
+func TestParseFENDefaultsCounters(t *testing.T) {
+	board, err := chess.ParseFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.HalfmoveClock != 0 || board.FullmoveNumber != 1 {
+		t.Errorf("expected counters 0 1, got %d %d", board.HalfmoveClock, board.FullmoveNumber)
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":                     "",
+		"too few fields":            "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w",
+		"seven ranks":               "rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"short rank":                "rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"long rank":                 "rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"consecutive digits":        "rnbqkbnr/pppppppp/53/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"split empty rank":          "rnbqkbnr/pppppppp/8/44/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"seven and one":             "rnbqkbnr/pppppppp/8/8/8/71/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"unknown piece":             "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1",
+		"bad side to move":          "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"bad castling character":    "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1",
+		"one character en passant":  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e 0 1",
+		"en passant wrong rank":     "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e5 0 2",
+		"en passant without pawn":   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1",
+		"negative halfmove clock":   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"zero fullmove number":      "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0",
+		"missing king":              "rnbq1bnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQ - 0 1",
+		"two kings":                 "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKKBNR w kq - 0 1",
+		"pawn on back rank":         "rnbqkbnP/pppppppp/8/8/8/8/PPPPPPP1/RNBQKBNR w KQq - 0 1",
+		"side not to move in check": "4k2R/8/8/8/8/8/8/4K3 w - - 0 1",
+	}
+
+	for name, fen := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := chess.ParseFEN(fen); err == nil {
+				t.Errorf("expected error for %q", fen)
+			}
+		})
+	}
+}
+
+func TestParseFENCastlingErrors(t *testing.T) {
+	tests := map[string]string{
+		"castling without rook":    "rnbqkbn1/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"castling with moved king": "rnbqkbnr/pppppppp/8/8/8/4K3/PPPPPPPP/RNBQ1BNR w KQkq - 0 1",
+	}
+
+	for name, fen := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := chess.ParseFEN(fen)
+			if err == nil || !strings.Contains(err.Error(), "castle") {
+				t.Errorf("expected a castling error for %q, got %v", fen, err)
+			}
+		})
+	}
+}
+
+func TestMustParseFENPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustParseFEN to panic on an invalid FEN")
+		}
+	}()
+	chess.MustParseFEN("not a fen")
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func pushMoves(t *testing.T, game *chess.Game, moves ...string) {
+	t.Helper()
+	for _, text := range moves {
+		move, err := game.Board().ValidateMove(text[0:2], text[2:4], text[4:])
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if err := game.Push(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGamePushPop(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	pushMoves(t, game, "e2e4", "e7e5", "g1f3", "b8c6")
+
+	if game.Ply() != 4 {
+		t.Fatalf("expected 4 plies, got %d", game.Ply())
+	}
+	var played []string
+	for _, move := range game.Moves() {
+		played = append(played, move.ToString())
+	}
+	if len(played) != 4 || played[0] != "e2e4" || played[3] != "b8c6" {
+		t.Errorf("unexpected moves %v", played)
+	}
+
+	move, ok := game.Pop()
+	if !ok || move.ToString() != "b8c6" {
+		t.Fatalf("expected to pop b8c6, got %s %v", move.ToString(), ok)
+	}
+	if fen := game.Board().ToFEN(); fen != "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2" {
+		t.Errorf("unexpected position after pop: %s", fen)
+	}
+
+	for game.Ply() > 0 {
+		game.Pop()
+	}
+	if !game.Board().Equal(chess.NewBoardFromFEN(chess.StartingFEN)) {
+		t.Errorf("popping every move did not restore the start: %s", game.Board().ToFEN())
+	}
+	if _, ok := game.Pop(); ok {
+		t.Error("pop on an empty game should report false")
+	}
+}
+
+func TestGamePushIllegal(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	if err := game.Push(chess.NewMove(chess.E2, chess.E5, chess.FlagQuietMove)); err == nil {
+		t.Error("expected an error for an illegal move")
+	}
+	if game.Ply() != 0 || !game.Board().Equal(chess.NewBoardFromFEN(chess.StartingFEN)) {
+		t.Error("illegal move changed the game")
+	}
+}
+
+func TestGameHistory(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	if history := game.History(); len(history) != 0 {
+		t.Fatalf("expected no history before any move, got %d hashes", len(history))
+	}
+
+	pushMoves(t, game, "e2e4", "e7e5")
+	history := game.History()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 hashes, got %d", len(history))
+	}
+	for ply, hash := range history {
+		position, _ := game.PositionAt(ply)
+		if hash != position.Hash {
+			t.Errorf("ply %d: hash does not match the position", ply)
+		}
+	}
+}
+
+func TestGamePositionAt(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	pushMoves(t, game, "d2d4", "d7d5", "c2c4")
+
+	tests := map[string]struct {
+		ply int
+		fen string
+	}{
+		"start": {
+			ply: 0,
+			fen: chess.StartingFEN,
+		},
+		"after d5": {
+			ply: 2,
+			fen: "rnbqkbnr/ppp1pppp/8/3p4/3P4/8/PPP1PPPP/RNBQKBNR w KQkq d6 0 2",
+		},
+		"current": {
+			ply: 3,
+			fen: "rnbqkbnr/ppp1pppp/8/3p4/2PP4/8/PP2PPPP/RNBQKBNR b KQkq c3 0 2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board, err := game.PositionAt(test.ply)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fen := board.ToFEN(); fen != test.fen {
+				t.Errorf("ply %d: expected %q, got %q", test.ply, test.fen, fen)
+			}
+		})
+	}
+
+	for _, ply := range []int{-1, 4} {
+		if _, err := game.PositionAt(ply); err == nil {
+			t.Errorf("expected error for ply %d", ply)
+		}
+	}
+	if game.Ply() != 3 {
+		t.Errorf("PositionAt changed the game")
+	}
+}
+
+func TestGameRepetitionCount(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	if count := game.RepetitionCount(); count != 1 {
+		t.Fatalf("expected 1 occurrence at the start, got %d", count)
+	}
+
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	pushMoves(t, game, shuffle...)
+	if count := game.RepetitionCount(); count != 2 {
+		t.Errorf("expected 2 occurrences after one shuffle, got %d", count)
+	}
+	pushMoves(t, game, shuffle...)
+	if count := game.RepetitionCount(); count != 3 {
+		t.Errorf("expected 3 occurrences after two shuffles, got %d", count)
+	}
+
+	game.Pop()
+	if count := game.RepetitionCount(); count != 2 {
+		t.Errorf("expected 2 occurrences after taking a move back, got %d", count)
+	}
+
+	pushMoves(t, game, "f6g8", "e2e4")
+	if count := game.RepetitionCount(); count != 1 {
+		t.Errorf("a pawn move cannot repeat an earlier position, got %d", count)
+	}
+}
+
+func TestGameDraws(t *testing.T) {
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	tests := map[string]struct {
+		fen         string
+		moves       []string
+		termination chess.Termination
+		claimable   chess.Termination
+	}{
+		"ongoing": {
+			fen:         chess.StartingFEN,
+			moves:       shuffle,
+			termination: chess.Ongoing,
+			claimable:   chess.Ongoing,
+		},
+		"threefold repetition is claimable": {
+			fen:         chess.StartingFEN,
+			moves:       append(append([]string{}, shuffle...), shuffle...),
+			termination: chess.Ongoing,
+			claimable:   chess.ThreefoldRepetition,
+		},
+		"fivefold repetition is automatic": {
+			fen:         chess.StartingFEN,
+			moves:       append(append(append(append([]string{}, shuffle...), shuffle...), shuffle...), shuffle...),
+			termination: chess.FivefoldRepetition,
+			claimable:   chess.Ongoing,
+		},
+		"fifty-move rule is claimable": {
+			fen:         "4k3/8/8/8/8/8/8/R3K3 w - - 99 80",
+			moves:       []string{"a1a2"},
+			termination: chess.Ongoing,
+			claimable:   chess.FiftyMoveRule,
+		},
+		"seventy-five-move rule is automatic": {
+			fen:         "4k3/8/8/8/8/8/8/R3K3 w - - 149 100",
+			moves:       []string{"a1a2"},
+			termination: chess.SeventyFiveMoveRule,
+			claimable:   chess.Ongoing,
+		},
+		"checkmate takes precedence": {
+			fen:         "4k3/R7/8/8/8/8/8/1R2K3 w - - 149 100",
+			moves:       []string{"b1b8"},
+			termination: chess.Checkmate,
+			claimable:   chess.Ongoing,
+		},
+		"insufficient material": {
+			fen:         "4k3/8/8/8/8/8/3r4/4KB2 w - - 0 1",
+			moves:       []string{"e1d2"},
+			termination: chess.InsufficientMaterial,
+			claimable:   chess.Ongoing,
+		},
+		"same-coloured bishops": {
+			fen:         "2b1k3/8/8/8/8/8/8/4KB2 w - - 0 1",
+			termination: chess.InsufficientMaterial,
+			claimable:   chess.Ongoing,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := chess.NewGame(chess.NewBoardFromFEN(test.fen))
+			pushMoves(t, game, test.moves...)
+
+			if result := game.Status(); result.Termination != test.termination {
+				t.Errorf("expected %s, got %s", test.termination, result.Termination)
+			}
+			claimable, ok := game.ClaimableDraw()
+			if ok != (test.claimable != chess.Ongoing) || claimable != test.claimable {
+				t.Errorf("expected claimable %s, got %s (%v)", test.claimable, claimable, ok)
+			}
+
+			var legal chess.MoveList
+			game.Board().GenerateLegalMoves(&legal)
+			if test.termination != chess.Ongoing && legal.Len() > 0 {
+				if err := game.Push(legal.At(0)); err == nil {
+					t.Errorf("played %s after the game ended", legal.At(0).ToString())
+				}
+			}
+		})
+	}
+}
+
+func TestGameClaimDraw(t *testing.T) {
+	game := chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN))
+	if _, err := game.ClaimDraw(); err == nil {
+		t.Fatal("claimed a draw in the starting position")
+	}
+
+	pushMoves(t, game, "g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1", "f6g8")
+	result, err := game.ClaimDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Outcome != chess.Draw || result.Termination != chess.ThreefoldRepetition {
+		t.Errorf("expected a draw by threefold repetition, got %s", result)
+	}
+	if _, ok := game.ClaimableDraw(); ok {
+		t.Errorf("draw still claimable after the claim")
+	}
+
+	move, _ := game.Board().ValidateMove("e2", "e4")
+	if err := game.Push(move); err == nil {
+		t.Errorf("played a move after the draw was claimed")
+	}
+
+	game.Pop()
+	if result := game.Status(); result.IsOver() {
+		t.Errorf("taking a move back should withdraw the claim, got %s", result)
+	}
+}
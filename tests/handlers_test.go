@@ -0,0 +1,733 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"chess/chess"
+	"chess/handlers"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startGame(t *testing.T) handlers.GameState {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handlers.HandleStartGame(rec, httptest.NewRequest(http.MethodPost, "/start", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("start returned %d", rec.Code)
+	}
+
+	var state handlers.GameState
+	if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state.GameID == "" {
+		t.Fatal("start did not return a game id")
+	}
+	return state
+}
+
+// postJSON posts body to h as JSON and decodes the response into a T.
+func postJSON[T any](t *testing.T, h http.HandlerFunc, body any) (int, T) {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)))
+
+	var response T
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, response
+}
+
+func TestGameSessions(t *testing.T) {
+	first := startGame(t)
+	second := startGame(t)
+	if first.GameID == second.GameID {
+		t.Fatal("games share an id")
+	}
+
+	code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: first.GameID, From: "e2", To: "e4"})
+	if code != http.StatusOK || response.SAN != "e4" {
+		t.Fatalf("move in first game failed: %d %+v", code, response)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: second.GameID, From: "d2", To: "d4"})
+	if code != http.StatusOK {
+		t.Fatalf("move in second game failed: %d %+v", code, response)
+	}
+	if response.FEN != "rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3 0 1" {
+		t.Errorf("second game was affected by the first: %s", response.FEN)
+	}
+
+	rec := httptest.NewRecorder()
+	handlers.HandleGetMoves(rec, httptest.NewRequest(http.MethodGet, "/moves?game_id="+first.GameID, nil))
+	var state handlers.GameState
+	json.NewDecoder(rec.Body).Decode(&state)
+	if state.FEN != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" {
+		t.Errorf("unexpected first game position: %s", state.FEN)
+	}
+	if state.MoveCount != 20 {
+		t.Errorf("expected 20 legal moves, got %d", state.MoveCount)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: first.GameID, From: "e4", To: "e5"})
+	if code != http.StatusBadRequest || response.Success {
+		t.Errorf("illegal move should be rejected, got %d %+v", code, response)
+	}
+
+	code, _ = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: "missing", From: "e2", To: "e4"})
+	if code != http.StatusNotFound {
+		t.Errorf("unknown game should return 404, got %d", code)
+	}
+}
+
+func TestStatelessMove(t *testing.T) {
+	code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{
+		FEN:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		From: "e7",
+		To:   "e5",
+	})
+	if code != http.StatusOK || response.GameID != "" {
+		t.Fatalf("stateless move failed: %d %+v", code, response)
+	}
+	if response.FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("unexpected fen: %s", response.FEN)
+	}
+}
+
+func TestStatelessMoveRepetition(t *testing.T) {
+	code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{
+		Moves: []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1"},
+		From:  "f6",
+		To:    "g8",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("stateless move failed: %d %+v", code, response)
+	}
+	if response.Status == nil || response.Status.Over || response.Status.Claimable != "threefold repetition" {
+		t.Errorf("expected a claimable threefold repetition, got %+v", response.Status)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{
+		Moves: []string{"e2e5"},
+		From:  "e7",
+		To:    "e5",
+	})
+	if code != http.StatusBadRequest || response.Success {
+		t.Errorf("illegal earlier move should be rejected, got %d %+v", code, response)
+	}
+}
+
+func TestMoveAfterFivefoldRepetition(t *testing.T) {
+	state := startGame(t)
+	var response handlers.MoveResponse
+	for i := 0; i < 4; i++ {
+		for _, squares := range [][2]string{{"g1", "f3"}, {"g8", "f6"}, {"f3", "g1"}, {"f6", "g8"}} {
+			var code int
+			code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: state.GameID, From: squares[0], To: squares[1]})
+			if code != http.StatusOK {
+				t.Fatalf("%s%s failed: %d %+v", squares[0], squares[1], code, response)
+			}
+		}
+	}
+	if response.Status == nil || response.Status.Termination != chess.FivefoldRepetition.String() {
+		t.Fatalf("expected fivefold repetition, got %+v", response.Status)
+	}
+
+	code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: state.GameID, From: "e2", To: "e4"})
+	if code != http.StatusBadRequest || response.Success {
+		t.Errorf("move after fivefold repetition should be rejected, got %d %+v", code, response)
+	}
+}
+
+func TestGameStoreTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := handlers.NewGameStore(time.Minute)
+	store.Clock = func() time.Time { return now }
+	id := store.Create(chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN)))
+
+	now = now.Add(50 * time.Second)
+	if _, err := store.Get(id); err != nil {
+		t.Fatalf("fresh game missing: %v", err)
+	}
+
+	now = now.Add(50 * time.Second)
+	if _, err := store.Get(id); err != nil {
+		t.Fatalf("access should extend the game's lifetime: %v", err)
+	}
+
+	now = now.Add(61 * time.Second)
+	if _, err := store.Get(id); err != handlers.ErrGameNotFound {
+		t.Errorf("expected expired game to be evicted, got %v", err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("expected empty store, got %d games", store.Len())
+	}
+}
+
+func TestGameStoreEvictionDoesNotWaitForGames(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := handlers.NewGameStore(time.Minute)
+	store.Clock = func() time.Time { return now }
+	busy := store.Create(chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN)))
+	store.Create(chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN)))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go store.Update(busy, func(game *chess.Game) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	now = now.Add(2 * time.Minute)
+	done := make(chan int)
+	go func() {
+		store.Create(chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN)))
+		done <- store.Len()
+	}()
+	select {
+	case count := <-done:
+		if count != 1 {
+			t.Errorf("expected both old games to be evicted, got %d games", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("eviction blocked on a game in use")
+	}
+}
+
+func TestGameStoreConcurrentMoves(t *testing.T) {
+	store := handlers.NewGameStore(handlers.DefaultGameTTL)
+	id := store.Create(chess.NewGame(chess.NewBoardFromFEN(chess.StartingFEN)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				store.Update(id, func(game *chess.Game) error {
+					if err := game.Push(chess.GenerateAllLegalMoves(game.Board())[0]); err != nil {
+						return err
+					}
+					game.Pop()
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	board, _ := store.Get(id)
+	if board.ToFEN() != chess.StartingFEN {
+		t.Errorf("board corrupted by concurrent updates: %s", board.ToFEN())
+	}
+}
+
+func TestUndoGame(t *testing.T) {
+	state := startGame(t)
+	for _, move := range []handlers.MoveRequest{{From: "e2", To: "e4"}, {From: "e7", To: "e5"}} {
+		move.GameID = state.GameID
+		if code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, move); code != http.StatusOK {
+			t.Fatalf("move failed: %d %+v", code, response)
+		}
+	}
+
+	code, response := postJSON[handlers.MoveResponse](t, handlers.HandleUndo, handlers.UndoRequest{GameID: state.GameID})
+	if code != http.StatusOK || response.Move != "e7e5" || response.SAN != "e5" {
+		t.Fatalf("undo failed: %d %+v", code, response)
+	}
+	if response.FEN != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" {
+		t.Errorf("unexpected fen after undo: %s", response.FEN)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandleUndo, handlers.UndoRequest{GameID: state.GameID})
+	if code != http.StatusOK || response.FEN != chess.StartingFEN || len(response.LegalMoves) != 20 {
+		t.Fatalf("second undo failed: %d %+v", code, response)
+	}
+
+	code, _ = postJSON[handlers.MoveResponse](t, handlers.HandleUndo, handlers.UndoRequest{GameID: state.GameID})
+	if code != http.StatusBadRequest {
+		t.Errorf("undo with no moves should fail, got %d", code)
+	}
+
+	code, _ = postJSON[handlers.MoveResponse](t, handlers.HandleUndo, handlers.UndoRequest{GameID: "missing"})
+	if code != http.StatusNotFound {
+		t.Errorf("unknown game should return 404, got %d", code)
+	}
+}
+
+func TestStatelessUndo(t *testing.T) {
+	tests := map[string]struct {
+		request handlers.UndoRequest
+		code    int
+		fen     string
+	}{
+		"from the starting position": {
+			request: handlers.UndoRequest{Moves: []string{"e2e4", "e7e5", "g1f3"}},
+			code:    http.StatusOK,
+			fen:     "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		},
+		"from a fen": {
+			request: handlers.UndoRequest{FEN: "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", Moves: []string{"a7a8q"}},
+			code:    http.StatusOK,
+			fen:     "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+		},
+		"no moves": {
+			request: handlers.UndoRequest{},
+			code:    http.StatusBadRequest,
+		},
+		"illegal move": {
+			request: handlers.UndoRequest{Moves: []string{"e2e5"}},
+			code:    http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, response := postJSON[handlers.MoveResponse](t, handlers.HandleUndo, test.request)
+			if code != test.code {
+				t.Fatalf("expected %d, got %d %+v", test.code, code, response)
+			}
+			if response.FEN != test.fen {
+				t.Errorf("expected fen %q, got %q", test.fen, response.FEN)
+			}
+		})
+	}
+}
+
+func TestClaimDrawGame(t *testing.T) {
+	state := startGame(t)
+	code, _ := postJSON[handlers.MoveResponse](t, handlers.HandleDraw, handlers.DrawRequest{GameID: state.GameID})
+	if code != http.StatusBadRequest {
+		t.Errorf("draw claim in the starting position should fail, got %d", code)
+	}
+
+	var response handlers.MoveResponse
+	for _, text := range []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1"} {
+		move := handlers.MoveRequest{GameID: state.GameID, From: text[:2], To: text[2:]}
+		if code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, move); code != http.StatusOK {
+			t.Fatalf("move failed: %d %+v", code, response)
+		}
+	}
+	if response.Status.Claimable != "" {
+		t.Errorf("no draw should be claimable yet, got %q", response.Status.Claimable)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandleDraw, handlers.DrawRequest{GameID: state.GameID, Move: "f6g8"})
+	if code != http.StatusOK || response.Move != "f6g8" || response.SAN != "Ng8" {
+		t.Fatalf("draw claim with a move failed: %d %+v", code, response)
+	}
+	if !response.Status.Over || response.Status.Termination != "threefold repetition" || response.Status.Result != "1/2-1/2" {
+		t.Errorf("unexpected status after the claim: %+v", response.Status)
+	}
+
+	code, response = postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: state.GameID, From: "e2", To: "e4"})
+	if code != http.StatusBadRequest {
+		t.Errorf("move after a draw should fail, got %d %+v", code, response)
+	}
+
+	code, _ = postJSON[handlers.MoveResponse](t, handlers.HandleDraw, handlers.DrawRequest{GameID: "missing"})
+	if code != http.StatusNotFound {
+		t.Errorf("unknown game should return 404, got %d", code)
+	}
+}
+
+func TestStatelessClaimDraw(t *testing.T) {
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1", "f6g8"}
+	tests := map[string]struct {
+		request     handlers.DrawRequest
+		code        int
+		termination string
+		fen         string
+	}{
+		"threefold repetition": {
+			request:     handlers.DrawRequest{Moves: shuffle},
+			code:        http.StatusOK,
+			termination: "threefold repetition",
+			fen:         "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 8 5",
+		},
+		"fifty-move rule": {
+			request:     handlers.DrawRequest{FEN: "4k3/8/8/8/8/8/8/R3K3 b - - 100 80"},
+			code:        http.StatusOK,
+			termination: "fifty-move rule",
+			fen:         "4k3/8/8/8/8/8/8/R3K3 b - - 100 80",
+		},
+		"announced move reaches fifty moves": {
+			request:     handlers.DrawRequest{FEN: "4k3/8/8/8/8/8/8/R3K3 w - - 99 80", Move: "a1a2"},
+			code:        http.StatusOK,
+			termination: "fifty-move rule",
+			fen:         "4k3/8/8/8/8/8/R7/4K3 b - - 100 80",
+		},
+		"no draw to claim": {
+			request: handlers.DrawRequest{Moves: []string{"e2e4"}},
+			code:    http.StatusBadRequest,
+		},
+		"rejected claim still plays the move": {
+			request:     handlers.DrawRequest{Move: "e2e4"},
+			code:        http.StatusBadRequest,
+			termination: "ongoing",
+			fen:         "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		},
+		"illegal move": {
+			request: handlers.DrawRequest{Move: "e2e5"},
+			code:    http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, response := postJSON[handlers.MoveResponse](t, handlers.HandleDraw, test.request)
+			if code != test.code {
+				t.Fatalf("expected %d, got %d %+v", test.code, code, response)
+			}
+			if response.FEN != test.fen {
+				t.Errorf("expected fen %q, got %q", test.fen, response.FEN)
+			}
+			if test.termination != "" && (response.Status == nil || response.Status.Termination != test.termination) {
+				t.Errorf("expected termination %q, got %+v", test.termination, response.Status)
+			}
+		})
+	}
+}
+
+func TestBestMove(t *testing.T) {
+	tests := map[string]struct {
+		request handlers.BestMoveRequest
+		code    int
+		move    string
+		san     string
+		mate    int
+	}{
+		"mate in one": {
+			request: handlers.BestMoveRequest{FEN: "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", Depth: 3},
+			code:    http.StatusOK,
+			move:    "d1d8",
+			san:     "Rd8#",
+			mate:    1,
+		},
+		"promotion": {
+			request: handlers.BestMoveRequest{FEN: "8/P6k/8/8/8/8/8/K7 w - - 0 1", Depth: 3},
+			code:    http.StatusOK,
+			move:    "a7a8q",
+			san:     "a8=Q",
+		},
+		"starting position under a time limit": {
+			request: handlers.BestMoveRequest{MoveTime: 100},
+			code:    http.StatusOK,
+		},
+		"checkmated": {
+			request: handlers.BestMoveRequest{FEN: "6k1/5ppp/8/8/8/8/r4PPP/r5K1 w - - 0 1"},
+			code:    http.StatusBadRequest,
+		},
+		"invalid fen": {
+			request: handlers.BestMoveRequest{FEN: "not a fen"},
+			code:    http.StatusBadRequest,
+		},
+		"depth too large": {
+			request: handlers.BestMoveRequest{Depth: 100},
+			code:    http.StatusBadRequest,
+		},
+		"unknown game": {
+			request: handlers.BestMoveRequest{GameID: "missing"},
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, response := postJSON[handlers.BestMoveResponse](t, handlers.HandleBestMove, test.request)
+			if code != test.code {
+				t.Fatalf("expected %d, got %d %+v", test.code, code, response)
+			}
+			if code != http.StatusOK {
+				return
+			}
+			if test.move != "" && (response.Move != test.move || response.SAN != test.san) {
+				t.Errorf("expected %s (%s), got %s (%s)", test.move, test.san, response.Move, response.SAN)
+			}
+			if response.Mate != test.mate {
+				t.Errorf("expected mate in %d, got %d", test.mate, response.Mate)
+			}
+			if response.Depth == 0 || response.Nodes == 0 || len(response.PV) == 0 ||
+				response.PV[0] != response.Move || len(response.PVSAN) != len(response.PV) {
+				t.Errorf("incomplete search report: %+v", response)
+			}
+		})
+	}
+}
+
+func TestBestMoveGame(t *testing.T) {
+	state := startGame(t)
+	if code, response := postJSON[handlers.MoveResponse](t, handlers.HandlePostMove, handlers.MoveRequest{GameID: state.GameID, From: "e2", To: "e4"}); code != http.StatusOK {
+		t.Fatalf("move failed: %d %+v", code, response)
+	}
+
+	code, response := postJSON[handlers.BestMoveResponse](t, handlers.HandleBestMove, handlers.BestMoveRequest{GameID: state.GameID, Depth: 2})
+	if code != http.StatusOK {
+		t.Fatalf("bestmove failed: %d %+v", code, response)
+	}
+	board := chess.NewBoardFromFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if _, err := board.ValidateMove(response.Move[:2], response.Move[2:4], response.Move[4:]); err != nil {
+		t.Errorf("engine move %s is not legal for black after e4: %v", response.Move, err)
+	}
+}
+
+func TestBestMoveSeesRepetitions(t *testing.T) {
+	code, response := postJSON[handlers.BestMoveResponse](t, handlers.HandleBestMove, handlers.BestMoveRequest{
+		FEN:   "7k/8/8/8/8/8/8/3Q2K1 w - - 0 1",
+		Moves: []string{"d1d2", "h8g8", "d2d1"},
+		Depth: 3,
+	})
+	if code != http.StatusOK {
+		t.Fatalf("bestmove failed: %d %+v", code, response)
+	}
+	if response.Move != "g8h8" || response.Score != 0 {
+		t.Errorf("expected g8h8 drawing by repetition, got %s scoring %d", response.Move, response.Score)
+	}
+
+	code, response = postJSON[handlers.BestMoveResponse](t, handlers.HandleBestMove, handlers.BestMoveRequest{Moves: []string{"e2e5"}})
+	if code != http.StatusBadRequest || response.Success {
+		t.Errorf("illegal move should be rejected, got %d %+v", code, response)
+	}
+}
+
+func TestBestMoveCancelled(t *testing.T) {
+	body, _ := json.Marshal(handlers.BestMoveRequest{Depth: 20, MoveTime: 10000})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/bestmove", bytes.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handlers.HandleBestMove(rec, req)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("search ran for %v after the request was abandoned", elapsed)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected no response to an abandoned request, got %s", rec.Body.String())
+	}
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readEvent(t *testing.T, scanner *bufio.Scanner) (sseEvent, bool) {
+	t.Helper()
+	var event sseEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			return event, true
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return event, false
+}
+
+func TestAnalyzeStreamsUntilDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/analyze?fen="+url.QueryEscape(kiwipeteFEN), nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handlers.HandleAnalyze(rec, req)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("analysis ran for %v after the client went away", elapsed)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("expected an event stream, got %q", contentType)
+	}
+
+	scanner := bufio.NewScanner(rec.Body)
+	event, _ := readEvent(t, scanner)
+	var started handlers.AnalysisStart
+	if event.name != "start" || json.Unmarshal([]byte(event.data), &started) != nil || started.FEN != kiwipeteFEN {
+		t.Fatalf("expected a start event, got %+v", event)
+	}
+
+	depth := 0
+	for {
+		event, ok := readEvent(t, scanner)
+		if !ok {
+			break
+		}
+		if event.name != "info" {
+			t.Fatalf("expected only info events after the client left, got %+v", event)
+		}
+		var info handlers.AnalysisInfo
+		if err := json.Unmarshal([]byte(event.data), &info); err != nil {
+			t.Fatal(err)
+		}
+		if info.Depth != depth+1 {
+			t.Errorf("expected depth %d, got %d", depth+1, info.Depth)
+		}
+		depth = info.Depth
+		if len(info.Lines) != 1 || len(info.Lines[0].PV) == 0 || info.Lines[0].PV[0] != info.Lines[0].Move {
+			t.Errorf("depth %d: bad lines %+v", depth, info.Lines)
+		}
+	}
+	if depth < 2 {
+		t.Errorf("expected at least two depths, got %d", depth)
+	}
+}
+
+func TestAnalyzeMoveTime(t *testing.T) {
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handlers.HandleAnalyze(rec, httptest.NewRequest(http.MethodGet, "/analyze?movetime=200", nil))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("analysis ran for %v with a 200ms limit", elapsed)
+	}
+
+	scanner := bufio.NewScanner(rec.Body)
+	var last sseEvent
+	for {
+		event, ok := readEvent(t, scanner)
+		if !ok {
+			break
+		}
+		last = event
+	}
+	var info handlers.AnalysisInfo
+	if last.name != "done" || json.Unmarshal([]byte(last.data), &info) != nil || len(info.Lines) != 1 {
+		t.Errorf("expected the stream to end with a done event, got %+v", last)
+	}
+}
+
+func TestAnalyzeLimitsConcurrentSearches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handlers.HandleAnalyze))
+	defer server.Close()
+
+	var streams []*http.Response
+	defer func() {
+		for _, stream := range streams {
+			stream.Body.Close()
+		}
+	}()
+	busy := false
+	for len(streams) < 32 && !busy {
+		resp, err := http.Get(server.URL + "?movetime=10000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			busy = true
+			break
+		}
+		streams = append(streams, resp)
+		if event, _ := readEvent(t, bufio.NewScanner(resp.Body)); event.name != "start" {
+			t.Fatalf("expected a start event, got %+v", event)
+		}
+	}
+	if !busy {
+		t.Fatalf("%d analyses ran at once", len(streams))
+	}
+	if code, response := postJSON[handlers.BestMoveResponse](t, handlers.HandleBestMove, handlers.BestMoveRequest{Depth: 1}); code != http.StatusServiceUnavailable || response.Success {
+		t.Errorf("bestmove should share the search limit, got %d %+v", code, response)
+	}
+
+	streams[0].Body.Close()
+	streams = streams[1:]
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(server.URL + "?movetime=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("closing an analysis did not free its slot: %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		code  int
+	}{
+		"invalid fen":  {query: "fen=nonsense", code: http.StatusBadRequest},
+		"checkmated":   {query: "fen=" + url.QueryEscape("6k1/5ppp/8/8/8/8/r4PPP/r5K1 w - - 0 1"), code: http.StatusBadRequest},
+		"unknown game": {query: "game_id=missing", code: http.StatusNotFound},
+		"zero lines":   {query: "multipv=0", code: http.StatusBadRequest},
+		"too many":     {query: "multipv=100", code: http.StatusBadRequest},
+		"no time":      {query: "movetime=0", code: http.StatusBadRequest},
+		"too long":     {query: "movetime=3600000", code: http.StatusBadRequest},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handlers.HandleAnalyze(rec, httptest.NewRequest(http.MethodGet, "/analyze?"+test.query, nil))
+			if rec.Code != test.code {
+				t.Errorf("expected %d, got %d %s", test.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAnalyzeMultiPV(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/analyze?multipv=3", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handlers.HandleAnalyze(rec, req)
+
+	scanner := bufio.NewScanner(rec.Body)
+	infos := 0
+	for {
+		event, ok := readEvent(t, scanner)
+		if !ok {
+			break
+		}
+		if event.name != "info" {
+			continue
+		}
+		infos++
+
+		var info handlers.AnalysisInfo
+		if err := json.Unmarshal([]byte(event.data), &info); err != nil {
+			t.Fatal(err)
+		}
+		if len(info.Lines) != 3 {
+			t.Fatalf("depth %d: expected 3 lines, got %d", info.Depth, len(info.Lines))
+		}
+		seen := make(map[string]bool)
+		for i, line := range info.Lines {
+			if seen[line.Move] || line.PV[0] != line.Move || line.PVSAN[0] != line.SAN {
+				t.Errorf("depth %d line %d: bad line %+v", info.Depth, i+1, line)
+			}
+			seen[line.Move] = true
+			if i > 0 && line.Score > info.Lines[i-1].Score {
+				t.Errorf("depth %d: lines out of order", info.Depth)
+			}
+		}
+	}
+	if infos == 0 {
+		t.Errorf("expected info events, got:\n%s", rec.Body.String())
+	}
+}
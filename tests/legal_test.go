@@ -0,0 +1,86 @@
+package main
+
+import (
+	"chess/chess"
+	"math/bits"
+	"sort"
+	"testing"
+)
+
+func trialLegalMoves(board *chess.Board) []chess.Move {
+	var legal []chess.Move
+	us, them := chess.Black, chess.White
+	if board.WhiteToMove {
+		us, them = chess.White, chess.Black
+	}
+	for _, move := range chess.GenerateAllPossibleMoves(board) {
+		undoInfo := board.MakeMove(move)
+		if !chess.IsSquareAttacked(bits.TrailingZeros64(*board.GetBitboard(chess.King, us)), them, board) {
+			legal = append(legal, move)
+		}
+		board.UndoMove(move, undoInfo)
+	}
+	return legal
+}
+
+func sortedMoveStrings(moves []chess.Move) []string {
+	result := make([]string, len(moves))
+	for i, move := range moves {
+		result[i] = move.ToString()
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestLegalMovesMatchTrialFiltering(t *testing.T) {
+	tests := map[string]string{
+		"double check":                  "4r2k/8/8/8/8/3n4/8/4K3 w - - 0 1",
+		"pinned knight":                 "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1",
+		"pinned bishop along pin ray":   "4k3/8/8/1b6/8/3B4/8/5K2 w - - 0 1",
+		"en passant discovered check":   "8/8/8/K1pP3r/8/8/8/4k3 w - c6 0 1",
+		"en passant evades pawn check":  "8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1",
+		"en passant pinned diagonally":  "8/1k6/8/8/3Pp3/8/6B1/4K3 b - d3 0 1",
+		"castling through attack":       "4k3/8/8/8/8/8/5r2/R3K2R w KQ - 0 1",
+		"castling queen side b-file":    "1r2k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+		"king cannot retreat along ray": "4k3/8/8/8/4r3/8/4K3/8 w - - 0 1",
+		"promotion evasions":            "3r3k/2P5/8/8/8/8/8/3K4 w - - 0 1",
+	}
+
+	for name, fen := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(fen)
+			got := sortedMoveStrings(chess.GenerateAllLegalMoves(board))
+			want := sortedMoveStrings(trialLegalMoves(board))
+			if len(got) != len(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLegalMovesMatchTrialFilteringRandomGames(t *testing.T) {
+	state := uint64(0x9e3779b97f4a7c15)
+	for game := 0; game < 200; game++ {
+		board := chess.NewBoardFromFEN(chess.StartingFEN)
+		for ply := 0; ply < 200; ply++ {
+			moves := chess.GenerateAllLegalMoves(board)
+			want := trialLegalMoves(board)
+			if len(moves) != len(want) {
+				t.Fatalf("%s: got %d moves, want %d", board.ToFEN(), len(moves), len(want))
+			}
+			if len(moves) == 0 {
+				break
+			}
+
+			state ^= state << 13
+			state ^= state >> 7
+			state ^= state << 17
+			board.MakeMove(moves[state%uint64(len(moves))])
+		}
+	}
+}
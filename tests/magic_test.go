@@ -0,0 +1,81 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func randomOccupancies(n int) []uint64 {
+	state := uint64(0x2545f4914f6cdd1d)
+	occupancies := make([]uint64, n)
+	for i := range occupancies {
+		state ^= state << 13
+		state ^= state >> 7
+		state ^= state << 17
+		occupancies[i] = state & (state >> 5)
+	}
+	return occupancies
+}
+
+func TestMagicAttacksMatchRays(t *testing.T) {
+	occupancies := append(randomOccupancies(4096), 0, ^uint64(0))
+
+	for sq := 0; sq < 64; sq++ {
+		for _, occupied := range occupancies {
+			if got, want := chess.RookAttacks(sq, occupied), chess.RookRayAttacks(sq, occupied); got != want {
+				t.Fatalf("rook on %s, occupancy %#x: got %#x, want %#x", chess.SquareName(uint16(sq)), occupied, got, want)
+			}
+			if got, want := chess.BishopAttacks(sq, occupied), chess.BishopRayAttacks(sq, occupied); got != want {
+				t.Fatalf("bishop on %s, occupancy %#x: got %#x, want %#x", chess.SquareName(uint16(sq)), occupied, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkRookRayAttacks(b *testing.B) {
+	occupancies := randomOccupancies(1024)
+	b.ResetTimer()
+	var sink uint64
+	for i := 0; i < b.N; i++ {
+		sink ^= chess.RookRayAttacks(i&63, occupancies[i&1023])
+	}
+	_ = sink
+}
+
+func BenchmarkRookMagicAttacks(b *testing.B) {
+	occupancies := randomOccupancies(1024)
+	b.ResetTimer()
+	var sink uint64
+	for i := 0; i < b.N; i++ {
+		sink ^= chess.RookAttacks(i&63, occupancies[i&1023])
+	}
+	_ = sink
+}
+
+func BenchmarkBishopRayAttacks(b *testing.B) {
+	occupancies := randomOccupancies(1024)
+	b.ResetTimer()
+	var sink uint64
+	for i := 0; i < b.N; i++ {
+		sink ^= chess.BishopRayAttacks(i&63, occupancies[i&1023])
+	}
+	_ = sink
+}
+
+func BenchmarkBishopMagicAttacks(b *testing.B) {
+	occupancies := randomOccupancies(1024)
+	b.ResetTimer()
+	var sink uint64
+	for i := 0; i < b.N; i++ {
+		sink ^= chess.BishopAttacks(i&63, occupancies[i&1023])
+	}
+	_ = sink
+}
+
+func BenchmarkPerftKiwipete(b *testing.B) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		chess.Perft(board, 3)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+const kiwipeteFEN = "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+
+func TestMoveListMatchesSlices(t *testing.T) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+
+	var list chess.MoveList
+	board.GenerateLegalMoves(&list)
+	legal := chess.GenerateAllLegalMoves(board)
+	if list.Len() != len(legal) {
+		t.Fatalf("legal: got %d moves, want %d", list.Len(), len(legal))
+	}
+	for i, move := range legal {
+		if list.At(i) != move {
+			t.Errorf("legal move %d: got %s, want %s", i, list.At(i).ToString(), move.ToString())
+		}
+	}
+
+	board.GeneratePseudoLegalMoves(&list)
+	if pseudo := chess.GenerateAllPossibleMoves(board); list.Len() != len(pseudo) {
+		t.Fatalf("pseudo-legal: got %d moves, want %d", list.Len(), len(pseudo))
+	}
+
+	if nodes := chess.Perft(board, 3); nodes != 97862 {
+		t.Errorf("perft 3: got %d, want 97862", nodes)
+	}
+}
+
+func TestMoveGenerationDoesNotAllocate(t *testing.T) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	var list chess.MoveList
+
+	tests := map[string]func(){
+		"legal":        func() { board.GenerateLegalMoves(&list) },
+		"pseudo-legal": func() { board.GeneratePseudoLegalMoves(&list) },
+		"perft":        func() { chess.Perft(board, 2) },
+	}
+
+	for name, generate := range tests {
+		t.Run(name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, generate); allocs != 0 {
+				t.Errorf("got %v allocs per run, want 0", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateLegalMoves(b *testing.B) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	var list chess.MoveList
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.GenerateLegalMoves(&list)
+	}
+}
+
+func BenchmarkGeneratePseudoLegalMoves(b *testing.B) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	var list chess.MoveList
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.GeneratePseudoLegalMoves(&list)
+	}
+}
+
+func BenchmarkGenerateAllLegalMoves(b *testing.B) {
+	board := chess.NewBoardFromFEN(kiwipeteFEN)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		chess.GenerateAllLegalMoves(board)
+	}
+}
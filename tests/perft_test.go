@@ -2,29 +2,10 @@ package main
 
 import (
 	"chess/chess"
+	"strings"
 	"testing"
 )
 
-func perft(board *chess.Board, depth int) uint64 {
-	if depth == 0 {
-		return 1
-	}
-
-	moves := chess.GenerateAllLegalMoves(board)
-	if depth == 1 {
-		return uint64(len(moves))
-	}
-
-	var nodes uint64
-	for _, move := range moves {
-		undoInfo := board.MakeMove(move)
-		nodes += perft(board, depth - 1)
-		board.UndoMove(move, undoInfo)
-	}
-
-	return nodes
-}
-
 func TestPerftInitialBoard(t *testing.T) {
 	tests := map[string]struct {
 		depth int
@@ -60,7 +41,7 @@ func TestPerftInitialBoard(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth)
+			result := chess.Perft(board, test.depth)
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
@@ -99,7 +80,7 @@ func TestKiwipetePosition(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth) 
+			result := chess.Perft(board, test.depth) 
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
@@ -146,7 +127,7 @@ func TestPosition3(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth)
+			result := chess.Perft(board, test.depth)
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
@@ -189,7 +170,7 @@ func TestPosition4EnPassant(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth)
+			result := chess.Perft(board, test.depth)
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
@@ -228,7 +209,7 @@ func TestPosition5Castling(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth)
+			result := chess.Perft(board, test.depth)
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
@@ -267,10 +248,71 @@ func TestPosition6Promotion(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := perft(board, test.depth)
+			result := chess.Perft(board, test.depth)
 			if result != test.nodes {
 				t.Errorf("Depth %d: expected %d nodes, got %d", test.depth, test.nodes, result)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestDivide(t *testing.T) {
+	board := chess.NewBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+
+	result := chess.Divide(board, 3)
+	if result.Nodes != 97862 {
+		t.Fatalf("expected 97862 nodes, got %d", result.Nodes)
+	}
+	if len(result.Entries) != 48 {
+		t.Fatalf("expected 48 root moves, got %d", len(result.Entries))
+	}
+
+	for _, entry := range result.Entries {
+		undoInfo := board.MakeMove(entry.Move)
+		nodes := chess.Perft(board, 2)
+		board.UndoMove(entry.Move, undoInfo)
+		if nodes != entry.Nodes {
+			t.Errorf("%s: divide reported %d, perft gives %d", entry.Move.ToString(), entry.Nodes, nodes)
+		}
+	}
+
+	output := result.String()
+	if !strings.HasSuffix(output, "\n\nNodes searched: 97862\n") {
+		t.Errorf("unexpected divide output ending: %q", output[len(output)-40:])
+	}
+	if !strings.Contains(output, "e1g1: ") {
+		t.Errorf("expected castling move in divide output:\n%s", output)
+	}
+}
+
+func TestPerftParallel(t *testing.T) {
+	tests := map[string]struct {
+		fen   string
+		depth int
+		nodes uint64
+	}{
+		"initial": {
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			depth: 4,
+			nodes: 197281,
+		},
+		"position 4": {
+			fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			depth: 4,
+			nodes: 422333,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(tt.fen)
+			before := board.ToFEN()
+			if nodes := chess.PerftParallel(board, tt.depth, 4); nodes != tt.nodes {
+				t.Errorf("expected %d nodes, got %d", tt.nodes, nodes)
+			}
+			if board.ToFEN() != before {
+				t.Errorf("board modified: %s", board.ToFEN())
+			}
+		})
+	}
+}
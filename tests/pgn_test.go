@@ -0,0 +1,167 @@
+package main
+
+import (
+	"chess/pgn"
+	"strings"
+	"testing"
+)
+
+const samplePGN = `[White "Fischer, Robert J."]
+[Black "Spassky, Boris V."]
+[Event "F/S Return Match"]
+[Annotator "Test"]
+[Result "1/2-1/2"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 {This opening is called the Ruy Lopez.} 3... a6
+4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3 O-O 9. h3 Nb8 10. d4 Nbd7
+11. c4 c6 12. cxb5 axb5 13. Nc3 Bb7 14. Bg5 b4 15. Nb1 h6 16. Bh4 c5 17. dxe5
+Nxe4 18. Bxe7 Qxe7 19. exd6 Qf6 20. Nbd2 Nxd6 21. Nc4 Nxc4 22. Bxc4 Nb6
+23. Ne5 Rae8 24. Bxf7+ Rxf7 25. Nxf7 Rxe1+ 26. Qxe1 Kxf7 27. Qe3 Qg5 28. Qxg5
+hxg5 29. b3 Ke6 30. a3 Kd6 31. axb4 cxb4 32. Ra5 Nd5 33. f3 Bc8 34. Kf2 Bf5
+35. Ra7 g6 36. Ra6+ Kc5 37. Ke1 Nf4 38. g3 Nxh3 39. Kd2 Kb5 40. Rd6 Kc5 41. Ra6
+Nf2 42. g4 Bd3 43. Re6 1/2-1/2
+
+[Event "Variations"]
+[SetUp "1"]
+[FEN "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2"]
+
+{Start} 2. Nf3 $1 (2. f4!? exf4 (2... d5 {Falkbeer}) 3. Nf3) (2. Nc3) 2... Nc6?! ; tricky
+3. Bb5 *
+`
+
+func TestParsePGN(t *testing.T) {
+	games, err := pgn.ParseString(samplePGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+
+	first := games[0]
+	if white, _ := first.Tag("White"); white != "Fischer, Robert J." {
+		t.Errorf("unexpected White tag %q", white)
+	}
+	if first.Result != "1/2-1/2" {
+		t.Errorf("expected result 1/2-1/2, got %s", first.Result)
+	}
+	mainline := first.Mainline()
+	if len(mainline) != 85 {
+		t.Errorf("expected 85 plies, got %d", len(mainline))
+	}
+	if mainline[4].Comment != "This opening is called the Ruy Lopez." {
+		t.Errorf("unexpected comment %q", mainline[4].Comment)
+	}
+
+	second := games[1]
+	if second.Root.Comment != "Start" {
+		t.Errorf("unexpected game comment %q", second.Root.Comment)
+	}
+	if len(second.Root.Children) != 3 {
+		t.Fatalf("expected 3 alternatives for the first move, got %d", len(second.Root.Children))
+	}
+	f4 := second.Root.Children[1]
+	if f4.SAN != "f4" || len(f4.NAGs) != 1 || f4.NAGs[0] != 5 {
+		t.Errorf("unexpected variation move %s %v", f4.SAN, f4.NAGs)
+	}
+	if len(f4.Children) != 2 || f4.Children[1].Comment != "Falkbeer" {
+		t.Errorf("expected nested variation with comment")
+	}
+	nc6 := second.Mainline()[1]
+	if len(nc6.NAGs) != 1 || nc6.NAGs[0] != 6 || nc6.Comment != "tricky" {
+		t.Errorf("unexpected annotations on %s: %v %q", nc6.SAN, nc6.NAGs, nc6.Comment)
+	}
+}
+
+func TestParsePGNEnPassant(t *testing.T) {
+	games, err := pgn.ParseString("1. e4 f5 2. e5 d5 3. exd6 e.p. e6 4. d4 f4 5. g4 fxg3e.p. *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sans []string
+	for _, node := range games[0].Mainline() {
+		sans = append(sans, node.SAN)
+	}
+	if got := strings.Join(sans, " "); got != "e4 f5 e5 d5 exd6 e6 d4 f4 g4 fxg3" {
+		t.Errorf("unexpected moves %s", got)
+	}
+}
+
+func TestWritePGN(t *testing.T) {
+	games, err := pgn.ParseString(samplePGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := pgn.Write(&out, games); err != nil {
+		t.Fatal(err)
+	}
+	written := out.String()
+
+	for _, line := range strings.Split(written, "\n") {
+		if len(line) > 80 {
+			t.Errorf("line exceeds 80 columns: %q", line)
+		}
+	}
+
+	expectedHeader := `[Event "F/S Return Match"]
+[Site "?"]
+[Date "?"]
+[Round "?"]
+[White "Fischer, Robert J."]
+[Black "Spassky, Boris V."]
+[Result "1/2-1/2"]
+[Annotator "Test"]
+`
+	if !strings.HasPrefix(written, expectedHeader) {
+		t.Errorf("tags not in Seven Tag Roster order:\n%s", written)
+	}
+
+	if !strings.Contains(written, "(2. f4 $5 exf4 (2... d5 {Falkbeer}) 3. Nf3) (2. Nc3) 2... Nc6") {
+		t.Errorf("variations not written as expected:\n%s", written)
+	}
+
+	reparsed, err := pgn.ParseString(written)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rewritten strings.Builder
+	if err := pgn.Write(&rewritten, reparsed); err != nil {
+		t.Fatal(err)
+	}
+	if rewritten.String() != written {
+		t.Errorf("PGN did not round-trip:\n%s\n---\n%s", written, rewritten.String())
+	}
+}
+
+func TestWritePGNCommentWithBrace(t *testing.T) {
+	game := pgn.NewGame()
+	node, err := game.Root.AddSAN("e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Comment = "best by test} 1-0 {"
+
+	reparsed, err := pgn.ParseString(game.String())
+	if err != nil {
+		t.Fatalf("written PGN does not parse: %v\n%s", err, game.String())
+	}
+	mainline := reparsed[0].Mainline()
+	if len(mainline) != 1 || mainline[0].Comment != "best by test 1-0 {" {
+		t.Errorf("unexpected round trip:\n%s", game.String())
+	}
+}
+
+func TestParsePGNErrors(t *testing.T) {
+	for _, input := range []string{
+		"1. e4 e5 2. Ke3 *",
+		"1. e4 (1. d4 *",
+		"1. e4 ) *",
+		"[Event \"unterminated]\n1. e4 *",
+	} {
+		if _, err := pgn.ParseString(input); err == nil {
+			t.Errorf("expected error parsing %q", input)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func TestMoveToSAN(t *testing.T) {
+	tests := map[string]struct {
+		fen  string
+		uci  string
+		flag uint16
+		san  string
+	}{
+		"pawn push": {
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			uci:  "e2e4",
+			flag: chess.FlagDoublePawn,
+			san:  "e4",
+		},
+		"kingside castle": {
+			fen:  "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			uci:  "e1g1",
+			flag: chess.FlagKingCastle,
+			san:  "O-O",
+		},
+		"file disambiguation": {
+			fen:  "6k1/8/8/8/8/8/4K3/R6R w - - 0 1",
+			uci:  "a1d1",
+			flag: chess.FlagQuietMove,
+			san:  "Rad1",
+		},
+		"rank disambiguation": {
+			fen:  "7k/8/8/8/R7/8/8/R3K3 w - - 0 1",
+			uci:  "a1a2",
+			flag: chess.FlagQuietMove,
+			san:  "R1a2",
+		},
+		"promotion with check": {
+			fen:  "7k/P7/8/8/8/8/8/4K3 w - - 0 1",
+			uci:  "a7a8",
+			flag: chess.FlagPromoQueen,
+			san:  "a8=Q+",
+		},
+		"pawn capture": {
+			fen:  "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",
+			uci:  "e4d5",
+			flag: chess.FlagCapture,
+			san:  "exd5",
+		},
+		"checkmate": {
+			fen:  "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2",
+			uci:  "d8h4",
+			flag: chess.FlagQuietMove,
+			san:  "Qh4#",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(test.fen)
+			from, _ := chess.ParseSquare(test.uci[:2])
+			to, _ := chess.ParseSquare(test.uci[2:4])
+			move := chess.NewMove(from, to, test.flag)
+
+			if san := board.MoveToSAN(move); san != test.san {
+				t.Errorf("expected %s, got %s", test.san, san)
+			}
+
+			parsed, err := board.ParseSAN(test.san)
+			if err != nil {
+				t.Fatalf("ParseSAN(%s): %v", test.san, err)
+			}
+			if parsed != move {
+				t.Errorf("ParseSAN(%s): expected %s, got %s", test.san, move.ToString(), parsed.ToString())
+			}
+		})
+	}
+}
+
+func TestSANRoundTrip(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	}
+
+	for _, fen := range fens {
+		board := chess.NewBoardFromFEN(fen)
+		for _, move := range chess.GenerateAllLegalMoves(board) {
+			san := board.MoveToSAN(move)
+			parsed, err := board.ParseSAN(san)
+			if err != nil {
+				t.Errorf("%s: ParseSAN(%s): %v", fen, san, err)
+				continue
+			}
+			if parsed != move {
+				t.Errorf("%s: %s parsed as %s, expected %s", fen, san, parsed.ToString(), move.ToString())
+			}
+		}
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	board := chess.NewBoardFromFEN("6k1/8/8/8/8/8/4K3/R6R w - - 0 1")
+
+	for _, san := range []string{"Rd1", "Ke4", "Qd1", "e9", ""} {
+		if _, err := board.ParseSAN(san); err == nil {
+			t.Errorf("expected error parsing %q", san)
+		}
+	}
+}
+
+func TestParseSANEnPassant(t *testing.T) {
+	board := chess.NewBoardFromFEN("rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3")
+
+	for _, san := range []string{"exd6", "exd6 e.p.", "exd6e.p.", "exd6 e.p.+", "exd6+ e.p."} {
+		move, err := board.ParseSAN(san)
+		if err != nil {
+			t.Errorf("ParseSAN(%q): %v", san, err)
+			continue
+		}
+		if move.Flag() != chess.FlagEPCapture || move.ToString() != "e5d6" {
+			t.Errorf("%q parsed as %s, expected en passant e5d6", san, move.ToString())
+		}
+	}
+
+	for _, san := range []string{"e6 e.p.", "exf6 e.p.", "e.p."} {
+		if _, err := board.ParseSAN(san); err == nil {
+			t.Errorf("expected error parsing %q", san)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func TestSEE(t *testing.T) {
+	tests := map[string]struct {
+		fen  string
+		move string
+		see  int
+	}{
+		"undefended knight": {
+			fen:  "4k3/8/8/3n4/4P3/8/8/4K3 w - - 0 1",
+			move: "e4d5",
+			see:  320,
+		},
+		"undefended pawn": {
+			fen:  "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1",
+			move: "e1e5",
+			see:  100,
+		},
+		"defended pawn": {
+			fen:  "3rk3/8/8/3p4/8/8/8/3RK3 w - - 0 1",
+			move: "d1d5",
+			see:  -400,
+		},
+		"x-ray behind the attacker": {
+			fen:  "3rk3/8/8/3p4/8/8/3R4/3RK3 w - - 0 1",
+			move: "d2d5",
+			see:  100,
+		},
+		"knight into a long exchange": {
+			fen:  "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1",
+			move: "d3e5",
+			see:  -220,
+		},
+		"en passant": {
+			fen:  "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			move: "e5d6",
+			see:  100,
+		},
+		"promotion onto an attacked square": {
+			fen:  "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move: "a7a8q",
+			see:  -100,
+		},
+		"capturing promotion": {
+			fen:  "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move: "a7b8q",
+			see:  1300,
+		},
+		"king cannot recapture a defended piece": {
+			fen:  "3k4/3p4/8/8/8/8/3Q4/3RK3 w - - 0 1",
+			move: "d2d7",
+			see:  100,
+		},
+		"king recaptures": {
+			fen:  "3k4/3p4/8/8/8/8/3Q4/4K3 w - - 0 1",
+			move: "d2d7",
+			see:  -800,
+		},
+		"quiet move onto an attacked square": {
+			fen:  "4k3/8/8/3p4/8/8/8/2R1K3 w - - 0 1",
+			move: "c1c4",
+			see:  -500,
+		},
+		"safe quiet move": {
+			fen:  chess.StartingFEN,
+			move: "g1f3",
+			see:  0,
+		},
+		"castling": {
+			fen:  "4k3/8/8/8/8/8/8/4K2R w K - 0 1",
+			move: "e1g1",
+			see:  0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			board := chess.NewBoardFromFEN(test.fen)
+			move, err := board.ValidateMove(test.move[0:2], test.move[2:4], test.move[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if see := chess.SEE(board, move); see != test.see {
+				t.Errorf("%s: expected %d, got %d", test.move, test.see, see)
+			}
+		})
+	}
+}
+
+func BenchmarkSEE(b *testing.B) {
+	board := chess.NewBoardFromFEN("1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1")
+	move, _ := board.ValidateMove("d3", "e5")
+	for i := 0; i < b.N; i++ {
+		chess.SEE(board, move)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func TestBoardStatus(t *testing.T) {
+	tests := map[string]struct {
+		fen         string
+		outcome     chess.Outcome
+		termination chess.Termination
+	}{
+		"starting position": {
+			fen:         chess.StartingFEN,
+			outcome:     chess.NoOutcome,
+			termination: chess.Ongoing,
+		},
+		"black mates": {
+			fen:         "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+			outcome:     chess.BlackWins,
+			termination: chess.Checkmate,
+		},
+		"white mates": {
+			fen:         "R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1",
+			outcome:     chess.WhiteWins,
+			termination: chess.Checkmate,
+		},
+		"stalemate": {
+			fen:         "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+			outcome:     chess.Draw,
+			termination: chess.Stalemate,
+		},
+		"king against king": {
+			fen:         "8/8/4k3/8/8/3K4/8/8 w - - 0 1",
+			outcome:     chess.Draw,
+			termination: chess.InsufficientMaterial,
+		},
+		"lone knight": {
+			fen:         "8/8/4k3/8/8/3K4/8/6N1 w - - 0 1",
+			outcome:     chess.Draw,
+			termination: chess.InsufficientMaterial,
+		},
+		"lone bishop": {
+			fen:         "8/8/4k3/8/8/3K4/8/5b2 w - - 0 1",
+			outcome:     chess.Draw,
+			termination: chess.InsufficientMaterial,
+		},
+		"bishops on the same colour": {
+			fen:         "5b2/8/4k3/8/8/3K4/8/2B5 w - - 0 1",
+			outcome:     chess.Draw,
+			termination: chess.InsufficientMaterial,
+		},
+		"bishops on opposite colours": {
+			fen:         "2b5/8/4k3/8/8/3K4/8/2B5 w - - 0 1",
+			outcome:     chess.NoOutcome,
+			termination: chess.Ongoing,
+		},
+		"two knights": {
+			fen:         "8/8/4k3/8/8/3K4/8/1N4N1 w - - 0 1",
+			outcome:     chess.NoOutcome,
+			termination: chess.Ongoing,
+		},
+		"lone pawn": {
+			fen:         "8/8/4k3/8/8/3K4/4P3/8 w - - 0 1",
+			outcome:     chess.NoOutcome,
+			termination: chess.Ongoing,
+		},
+		"fifty-move rule must be claimed": {
+			fen:         "4k3/8/8/8/8/8/8/R3K3 w - - 100 80",
+			outcome:     chess.NoOutcome,
+			termination: chess.Ongoing,
+		},
+		"seventy-five-move rule": {
+			fen:         "4k3/8/8/8/8/8/8/R3K3 w - - 150 100",
+			outcome:     chess.Draw,
+			termination: chess.SeventyFiveMoveRule,
+		},
+		"checkmate on the seventy-fifth move": {
+			fen:         "R5k1/5ppp/8/8/8/8/8/6K1 b - - 150 100",
+			outcome:     chess.WhiteWins,
+			termination: chess.Checkmate,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := chess.NewBoardFromFEN(test.fen).Status()
+			if result.Outcome != test.outcome || result.Termination != test.termination {
+				t.Errorf("expected %s by %s, got %s by %s", test.outcome, test.termination, result.Outcome, result.Termination)
+			}
+			if result.IsOver() != (test.outcome != chess.NoOutcome) {
+				t.Errorf("IsOver() = %v for %s", result.IsOver(), result.Outcome)
+			}
+		})
+	}
+}
+
+func TestGameResultWinner(t *testing.T) {
+	tests := map[chess.Outcome]struct {
+		winner chess.Color
+		ok     bool
+	}{
+		chess.WhiteWins: {winner: chess.White, ok: true},
+		chess.BlackWins: {winner: chess.Black, ok: true},
+		chess.Draw:      {ok: false},
+		chess.NoOutcome: {ok: false},
+	}
+
+	for outcome, test := range tests {
+		winner, ok := chess.GameResult{Outcome: outcome}.Winner()
+		if ok != test.ok || (ok && winner != test.winner) {
+			t.Errorf("%s: expected winner %v (%v), got %v (%v)", outcome, test.winner, test.ok, winner, ok)
+		}
+	}
+}
+
+func TestSquareColours(t *testing.T) {
+	dark := []string{"a1", "c1", "e1", "g1", "b2", "h8", "d4"}
+	light := []string{"b1", "d1", "h1", "a2", "a8", "e4"}
+	for _, square := range dark {
+		index, _ := chess.ParseSquare(square)
+		if chess.DarkSquares&(uint64(1)<<index) == 0 {
+			t.Errorf("%s should be dark", square)
+		}
+	}
+	for _, square := range light {
+		index, _ := chess.ParseSquare(square)
+		if chess.LightSquares&(uint64(1)<<index) == 0 {
+			t.Errorf("%s should be light", square)
+		}
+	}
+	if chess.LightSquares&chess.DarkSquares != 0 || chess.LightSquares|chess.DarkSquares != ^uint64(0) {
+		t.Error("light and dark squares should partition the board")
+	}
+}
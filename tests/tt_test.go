@@ -0,0 +1,180 @@
+package main
+
+import (
+	"chess/chess"
+	"chess/engine"
+	"sync"
+	"testing"
+)
+
+func TestTranspositionTableStoreProbe(t *testing.T) {
+	tt := engine.NewTranspositionTable(1)
+	if tt.SizeMB() != 1 {
+		t.Fatalf("expected a 1 MB table, got %d MB", tt.SizeMB())
+	}
+
+	move := chess.NewMove(chess.E2, chess.E4, chess.FlagDoublePawn)
+	tt.Store(0x1234, move, -57, 6, 3, engine.BoundLower)
+
+	entry, ok := tt.Probe(0x1234, 3)
+	if !ok {
+		t.Fatal("stored entry not found")
+	}
+	expected := engine.TTEntry{Move: move, Score: -57, Depth: 6, Bound: engine.BoundLower}
+	if entry != expected {
+		t.Errorf("expected %+v, got %+v", expected, entry)
+	}
+	if _, ok := tt.Probe(0x1234+1<<40, 3); ok {
+		t.Errorf("found an entry for a different hash in the same bucket")
+	}
+
+	stats := tt.Stats()
+	if stats.Probes != 2 || stats.Hits != 1 || stats.Stores != 1 || stats.HitRate() != 0.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	tt.Clear()
+	if _, ok := tt.Probe(0x1234, 3); ok {
+		t.Errorf("entry survived Clear")
+	}
+	if stats := tt.Stats(); stats.Probes != 1 || stats.Hits != 0 {
+		t.Errorf("Clear did not reset stats: %+v", stats)
+	}
+}
+
+func TestTranspositionTableMateScores(t *testing.T) {
+	tests := map[string]struct {
+		score, storePly, probePly, expected int
+	}{
+		"mate found deeper in the tree": {
+			score:    engine.MateScore - 7,
+			storePly: 4,
+			probePly: 2,
+			expected: engine.MateScore - 5,
+		},
+		"mated found deeper in the tree": {
+			score:    -engine.MateScore + 9,
+			storePly: 6,
+			probePly: 1,
+			expected: -engine.MateScore + 4,
+		},
+		"normal score is unchanged": {
+			score:    250,
+			storePly: 6,
+			probePly: 1,
+			expected: 250,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt := engine.NewTranspositionTable(1)
+			tt.Store(42, 0, test.score, 3, test.storePly, engine.BoundExact)
+			entry, ok := tt.Probe(42, test.probePly)
+			if !ok {
+				t.Fatal("stored entry not found")
+			}
+			if entry.Score != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, entry.Score)
+			}
+		})
+	}
+}
+
+func TestTranspositionTableReplacement(t *testing.T) {
+	tt := engine.NewTranspositionTable(1)
+	move := chess.NewMove(chess.G1, chess.F3, chess.FlagQuietMove)
+
+	tt.Store(7, move, 10, 8, 0, engine.BoundExact)
+	tt.Store(7, 0, 20, 2, 0, engine.BoundLower)
+	if entry, _ := tt.Probe(7, 0); entry.Depth != 8 || entry.Score != 10 {
+		t.Errorf("a shallow bound replaced a deep entry: %+v", entry)
+	}
+	tt.Store(7, 0, 30, 7, 0, engine.BoundUpper)
+	if entry, _ := tt.Probe(7, 0); entry.Depth != 7 || entry.Move != move {
+		t.Errorf("expected the new entry to keep the old move, got %+v", entry)
+	}
+
+	// Fill the bucket, then store one more position in it: the shallowest
+	// entry goes.
+	for i, depth := range []int{5, 3, 9} {
+		tt.Store(7+uint64(i+1)<<32, 0, 0, depth, 0, engine.BoundExact)
+	}
+	tt.Store(7+4<<32, 0, 0, 4, 0, engine.BoundExact)
+	if _, ok := tt.Probe(7+2<<32, 0); ok {
+		t.Errorf("the shallowest entry was not replaced")
+	}
+	if _, ok := tt.Probe(7+4<<32, 0); !ok {
+		t.Errorf("the new entry was not stored")
+	}
+	if stats := tt.Stats(); stats.Overwrites != 1 {
+		t.Errorf("expected 1 overwrite, got %d", stats.Overwrites)
+	}
+
+	// Entries from earlier searches are replaced before deeper ones.
+	tt.NewSearch()
+	tt.NewSearch()
+	tt.Store(7+1<<32, 0, 0, 5, 0, engine.BoundExact)
+	tt.Store(7+5<<32, 0, 0, 1, 0, engine.BoundExact)
+	if _, ok := tt.Probe(7+1<<32, 0); !ok {
+		t.Errorf("the entry refreshed in this search was replaced")
+	}
+	if _, ok := tt.Probe(7+5<<32, 0); !ok {
+		t.Errorf("the new entry was not stored")
+	}
+}
+
+func TestTranspositionTableHashfull(t *testing.T) {
+	tt := engine.NewTranspositionTable(1)
+	if full := tt.Hashfull(); full != 0 {
+		t.Errorf("expected an empty table, got %d", full)
+	}
+	for hash := uint64(0); hash < 1<<16; hash++ {
+		tt.Store(hash*0x9e3779b97f4a7c15, 0, 0, 1, 0, engine.BoundExact)
+	}
+	if full := tt.Hashfull(); full < 900 {
+		t.Errorf("expected a nearly full table, got %d", full)
+	}
+	tt.NewSearch()
+	if full := tt.Hashfull(); full != 0 {
+		t.Errorf("entries from the last search should not count, got %d", full)
+	}
+}
+
+func TestTranspositionTableConcurrentAccess(t *testing.T) {
+	tt := engine.NewTranspositionTable(1)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 20000; i++ {
+				hash := uint64(i%512)<<32 | uint64(i%64)
+				score := int(hash % 1000)
+				tt.Store(hash, chess.Move(hash), score, worker+1, 0, engine.BoundExact)
+				if entry, ok := tt.Probe(hash^1<<32, 0); ok && entry.Score != int((hash^1<<32)%1000) {
+					t.Errorf("entry for %x has the wrong score %d", hash^1<<32, entry.Score)
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
+
+func TestSearchWithTranspositionTable(t *testing.T) {
+	e := engine.New()
+	board := chess.NewBoardFromFEN("7k/8/8/8/8/8/R7/1R5K w - - 0 1")
+	first := e.Search(board, engine.Limits{Depth: 4})
+	if first.Mate != 2 {
+		t.Fatalf("expected mate in 2, got %d", first.Mate)
+	}
+	if stats := e.TT.Stats(); stats.Stores == 0 || stats.Hits == 0 {
+		t.Errorf("search did not use the table: %+v", stats)
+	}
+
+	second := e.Search(board, engine.Limits{Depth: 4})
+	if second.Mate != 2 || second.Nodes > first.Nodes {
+		t.Errorf("repeated search: mate %d with %d nodes, first took %d", second.Mate, second.Nodes, first.Nodes)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"chess/uci"
+	"strings"
+	"testing"
+)
+
+func TestUCIHandshake(t *testing.T) {
+	var out strings.Builder
+	session := uci.NewSession(&out)
+	session.Run(strings.NewReader("uci\nisready\nquit\n"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasPrefix(lines[0], "id name ") {
+		t.Errorf("expected id name first, got %q", lines[0])
+	}
+	if lines[len(lines)-2] != "uciok" || lines[len(lines)-1] != "readyok" {
+		t.Errorf("expected uciok then readyok, got %q", lines)
+	}
+}
+
+func TestUCIGo(t *testing.T) {
+	tests := map[string]struct {
+		commands []string
+		bestMove string
+		info     bool
+	}{
+		"mate in one from fen": {
+			commands: []string{"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", "go depth 3"},
+			bestMove: "bestmove d1d8",
+			info:     true,
+		},
+		"mate in one after moves": {
+			commands: []string{"position startpos moves e2e4 e7e5 f1c4 b8c6 d1h5 g8f6", "go depth 3"},
+			bestMove: "bestmove h5f7",
+			info:     true,
+		},
+		"repetition before the root": {
+			commands: []string{"position fen 7k/8/8/8/8/8/8/3Q2K1 w - - 0 1 moves d1d2 h8g8 d2d1", "go depth 3"},
+			bestMove: "bestmove g8h8",
+			info:     true,
+		},
+		"no legal moves": {
+			commands: []string{"position fen 6k1/5ppp/8/8/8/8/r4PPP/r5K1 w - - 0 1", "go depth 3"},
+			bestMove: "bestmove 0000",
+		},
+		"infinite until stop": {
+			commands: []string{"position startpos", "go infinite", "stop"},
+			bestMove: "bestmove ",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out strings.Builder
+			session := uci.NewSession(&out)
+			for _, command := range tt.commands {
+				session.Execute(command)
+			}
+			session.Execute("position startpos")
+
+			output := out.String()
+			if strings.Count(output, "bestmove") != 1 {
+				t.Fatalf("expected exactly one bestmove, got:\n%s", output)
+			}
+			if !strings.Contains(output, tt.bestMove) {
+				t.Errorf("expected %q, got:\n%s", tt.bestMove, output)
+			}
+			if tt.info && !strings.Contains(output, "info depth 1 ") {
+				t.Errorf("expected info lines, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestUCIChess960(t *testing.T) {
+	tests := map[string]struct {
+		commands []string
+		want     string
+	}{
+		"king takes rook": {
+			commands: []string{"setoption name UCI_Chess960 value true", "position fen 4k3/8/8/8/8/8/8/4K2R w K - 0 1 moves e1h1"},
+			want:     "info string fen 4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+		"standard castling": {
+			commands: []string{"position fen 4k3/8/8/8/8/8/8/4K2R w K - 0 1 moves e1g1"},
+			want:     "info string fen 4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+		"king takes rook without option": {
+			commands: []string{"position fen 4k3/8/8/8/8/8/8/4K2R w K - 0 1 moves e1h1"},
+			want:     "info string position: move e1 to h1 is not legal",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out strings.Builder
+			session := uci.NewSession(&out)
+			for _, command := range tt.commands {
+				session.Execute(command)
+			}
+			session.Execute("d")
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("expected %q, got:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestUCIHashOption(t *testing.T) {
+	tests := map[string]struct {
+		command string
+		err     bool
+	}{
+		"valid size":       {command: "setoption name Hash value 32"},
+		"zero":             {command: "setoption name Hash value 0", err: true},
+		"too large":        {command: "setoption name Hash value 100000", err: true},
+		"not a number":     {command: "setoption name Hash value big", err: true},
+		"case insensitive": {command: "setoption name hash value 1"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out strings.Builder
+			session := uci.NewSession(&out)
+			session.Execute(tt.command)
+			if hasErr := strings.Contains(out.String(), "invalid Hash value"); hasErr != tt.err {
+				t.Errorf("expected error %v, got:\n%s", tt.err, out.String())
+			}
+
+			session.Execute("position startpos")
+			session.Execute("go depth 2")
+			session.Execute("position startpos")
+			if !strings.Contains(out.String(), " hashfull ") || !strings.Contains(out.String(), "bestmove ") {
+				t.Errorf("expected a search with hashfull info, got:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestUCIThreadsOption(t *testing.T) {
+	var out strings.Builder
+	session := uci.NewSession(&out)
+	session.Execute("setoption name Threads value 0")
+	if !strings.Contains(out.String(), "invalid Threads value") {
+		t.Errorf("expected an error for 0 threads, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	for _, command := range []string{
+		"setoption name Threads value 4",
+		"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1",
+		"go depth 3",
+		"position startpos",
+	} {
+		session.Execute(command)
+	}
+	if strings.Contains(out.String(), "info string") || !strings.Contains(out.String(), "bestmove d1d8") {
+		t.Errorf("expected mate with 4 threads, got:\n%s", out.String())
+	}
+}
+
+func TestUCIMultiPV(t *testing.T) {
+	var out strings.Builder
+	session := uci.NewSession(&out)
+	session.Execute("setoption name MultiPV value 0")
+	if !strings.Contains(out.String(), "invalid MultiPV value") {
+		t.Errorf("expected an error for 0 lines, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	for _, command := range []string{
+		"setoption name MultiPV value 3",
+		"position startpos",
+		"go depth 2",
+		"position startpos",
+	} {
+		session.Execute(command)
+	}
+	for _, want := range []string{"info depth 2 multipv 1 ", "info depth 2 multipv 2 ", "info depth 2 multipv 3 ", "bestmove "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "multipv 4") {
+		t.Errorf("reported more lines than asked for:\n%s", out.String())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"chess/chess"
+	"testing"
+)
+
+func verifyHashes(t *testing.T, board *chess.Board, depth int) {
+	if !board.VerifyHash() {
+		t.Fatalf("hash mismatch for %s", board.ToFEN())
+	}
+	if depth == 0 {
+		return
+	}
+
+	for _, move := range chess.GenerateAllLegalMoves(board) {
+		previousHash := board.Hash
+		undoInfo := board.MakeMove(move)
+		verifyHashes(t, board, depth-1)
+		board.UndoMove(move, undoInfo)
+		if board.Hash != previousHash {
+			t.Fatalf("hash not restored after undoing %s in %s", move.ToString(), board.ToFEN())
+		}
+	}
+}
+
+func TestZobristIncrementalHash(t *testing.T) {
+	tests := map[string]struct {
+		fen   string
+		depth int
+	}{
+		"initial": {
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			depth: 3,
+		},
+		"kiwipete": {
+			fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -",
+			depth: 3,
+		},
+		"position4": {
+			fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			depth: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifyHashes(t, chess.NewBoardFromFEN(test.fen), test.depth)
+		})
+	}
+}
+
+func TestZobristTransposition(t *testing.T) {
+	board := chess.NewBoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	startHash := board.Hash
+
+	for _, squares := range [][2]string{{"g1", "f3"}, {"g8", "f6"}, {"f3", "g1"}, {"f6", "g8"}} {
+		move, err := board.ValidateMove(squares[0], squares[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		board.MakeMove(move)
+	}
+
+	if board.Hash != startHash {
+		t.Errorf("expected transposed position to hash to %016x, got %016x", startHash, board.Hash)
+	}
+}
@@ -0,0 +1,361 @@
+package uci
+
+import (
+	"bufio"
+	"chess/chess"
+	"chess/engine"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	engineName   = "chess"
+	engineAuthor = "NedimBecic"
+
+	defaultMoveOverhead = 10 * time.Millisecond
+	maxHashSize         = 4096
+	maxThreads          = 256
+	maxMultiPV          = chess.MaxMoves
+)
+
+type Session struct {
+	out   io.Writer
+	outMu sync.Mutex
+
+	board        chess.Board
+	history      []uint64
+	engine       *engine.Engine
+	moveOverhead time.Duration
+	chess960     bool
+	multiPV      int
+
+	searching sync.WaitGroup
+	stop      chan struct{}
+	infinite  bool
+}
+
+func NewSession(out io.Writer) *Session {
+	s := &Session{
+		out:          out,
+		board:        *chess.MustParseFEN(chess.StartingFEN),
+		engine:       engine.New(),
+		moveOverhead: defaultMoveOverhead,
+		multiPV:      1,
+	}
+	s.engine.OnInfo = s.sendInfo
+	return s
+}
+
+func (s *Session) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !s.Execute(scanner.Text()) {
+			return nil
+		}
+	}
+	s.stopSearch()
+	return scanner.Err()
+}
+
+func (s *Session) Execute(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "uci":
+		s.send("id name %s", engineName)
+		s.send("id author %s", engineAuthor)
+		s.send("option name Hash type spin default %d min 1 max %d", engine.DefaultHashSize, maxHashSize)
+		s.send("option name Threads type spin default 1 min 1 max %d", maxThreads)
+		s.send("option name MultiPV type spin default 1 min 1 max %d", maxMultiPV)
+		s.send("option name Move Overhead type spin default %d min 0 max 5000", defaultMoveOverhead.Milliseconds())
+		s.send("option name UCI_Chess960 type check default false")
+		s.send("uciok")
+	case "isready":
+		s.send("readyok")
+	case "ucinewgame":
+		s.waitSearch()
+		s.engine.TT.Clear()
+		s.board = *chess.MustParseFEN(chess.StartingFEN)
+		s.history = nil
+	case "position":
+		s.waitSearch()
+		if err := s.position(args); err != nil {
+			s.send("info string %v", err)
+		}
+	case "go":
+		s.waitSearch()
+		s.goSearch(args)
+	case "stop":
+		s.stopSearch()
+	case "setoption":
+		s.waitSearch()
+		if err := s.setOption(args); err != nil {
+			s.send("info string %v", err)
+		}
+	case "d":
+		s.send("info string fen %s", s.board.ToFEN())
+	case "quit":
+		s.stopSearch()
+		return false
+	default:
+		s.send("info string unknown command %s", command)
+	}
+	return true
+}
+
+func (s *Session) send(format string, args ...interface{}) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintf(s.out, format+"\n", args...)
+}
+
+func (s *Session) position(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("position: missing startpos or fen")
+	}
+
+	movesAt := len(args)
+	for i, arg := range args {
+		if arg == "moves" {
+			movesAt = i
+			break
+		}
+	}
+
+	var board *chess.Board
+	switch args[0] {
+	case "startpos":
+		board = chess.MustParseFEN(chess.StartingFEN)
+	case "fen":
+		var err error
+		board, err = chess.ParseFEN(strings.Join(args[1:movesAt], " "))
+		if err != nil {
+			return fmt.Errorf("position: %w", err)
+		}
+	default:
+		return fmt.Errorf("position: expected startpos or fen, got %s", args[0])
+	}
+	if s.chess960 {
+		board.Chess960 = true
+	}
+
+	var history []uint64
+	if movesAt < len(args) {
+		for _, text := range args[movesAt+1:] {
+			move, err := parseMove(board, text)
+			if err != nil {
+				return fmt.Errorf("position: %w", err)
+			}
+			history = append(history, board.Hash)
+			board.MakeMove(move)
+		}
+	}
+
+	s.board = *board
+	s.history = history
+	return nil
+}
+
+func parseMove(board *chess.Board, text string) (chess.Move, error) {
+	if len(text) != 4 && len(text) != 5 {
+		return 0, fmt.Errorf("invalid move %s", text)
+	}
+	return board.ValidateMove(text[0:2], text[2:4], text[4:])
+}
+
+func (s *Session) goSearch(args []string) {
+	limits := engine.Limits{MultiPV: s.multiPV, History: s.history}
+	for i := 0; i < len(args); i++ {
+		value := func() int {
+			if i+1 >= len(args) {
+				return 0
+			}
+			i++
+			n, _ := strconv.Atoi(args[i])
+			return n
+		}
+
+		switch args[i] {
+		case "depth":
+			limits.Depth = value()
+		case "nodes":
+			limits.Nodes = uint64(value())
+		case "movetime":
+			limits.MoveTime = time.Duration(value()) * time.Millisecond
+		case "wtime":
+			limits.WhiteTime = time.Duration(value()) * time.Millisecond
+		case "btime":
+			limits.BlackTime = time.Duration(value()) * time.Millisecond
+		case "winc":
+			limits.WhiteInc = time.Duration(value()) * time.Millisecond
+		case "binc":
+			limits.BlackInc = time.Duration(value()) * time.Millisecond
+		case "movestogo":
+			limits.MovesToGo = value()
+		case "infinite":
+			limits.Infinite = true
+		}
+	}
+
+	if limits.MoveTime > 0 {
+		limits.MoveTime = s.applyOverhead(limits.MoveTime)
+	}
+	if limits.WhiteTime > 0 {
+		limits.WhiteTime = s.applyOverhead(limits.WhiteTime)
+	}
+	if limits.BlackTime > 0 {
+		limits.BlackTime = s.applyOverhead(limits.BlackTime)
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	s.infinite = limits.Infinite
+	done := s.engine.Go(&s.board, limits)
+
+	s.searching.Add(1)
+	go func() {
+		defer s.searching.Done()
+		result := <-done
+		if limits.Infinite {
+			<-stop
+		}
+		s.sendBestMove(result)
+	}()
+}
+
+func (s *Session) applyOverhead(d time.Duration) time.Duration {
+	d -= s.moveOverhead
+	if d < time.Millisecond {
+		d = time.Millisecond
+	}
+	return d
+}
+
+func (s *Session) waitSearch() {
+	if s.stop == nil {
+		return
+	}
+	if s.infinite {
+		s.stopSearch()
+		return
+	}
+	s.searching.Wait()
+	close(s.stop)
+	s.stop = nil
+}
+
+func (s *Session) stopSearch() {
+	if s.stop == nil {
+		return
+	}
+	s.engine.Stop()
+	close(s.stop)
+	s.searching.Wait()
+	s.stop = nil
+}
+
+func (s *Session) setOption(args []string) error {
+	var name, value []string
+	target := (*[]string)(nil)
+	for _, arg := range args {
+		switch arg {
+		case "name":
+			target = &name
+		case "value":
+			target = &value
+		default:
+			if target == nil {
+				return fmt.Errorf("setoption: expected name")
+			}
+			*target = append(*target, arg)
+		}
+	}
+
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "hash":
+		mb, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || mb < 1 || mb > maxHashSize {
+			return fmt.Errorf("setoption: invalid Hash value %q", strings.Join(value, " "))
+		}
+		s.engine.TT.Resize(mb)
+	case "threads":
+		threads, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || threads < 1 || threads > maxThreads {
+			return fmt.Errorf("setoption: invalid Threads value %q", strings.Join(value, " "))
+		}
+		s.engine.Threads = threads
+	case "multipv":
+		lines, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || lines < 1 || lines > maxMultiPV {
+			return fmt.Errorf("setoption: invalid MultiPV value %q", strings.Join(value, " "))
+		}
+		s.multiPV = lines
+	case "move overhead":
+		ms, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || ms < 0 || ms > 5000 {
+			return fmt.Errorf("setoption: invalid Move Overhead value %q", strings.Join(value, " "))
+		}
+		s.moveOverhead = time.Duration(ms) * time.Millisecond
+	case "uci_chess960":
+		enabled, err := strconv.ParseBool(strings.Join(value, " "))
+		if err != nil {
+			return fmt.Errorf("setoption: invalid UCI_Chess960 value %q", strings.Join(value, " "))
+		}
+		s.chess960 = enabled
+	default:
+		return fmt.Errorf("setoption: unknown option %q", strings.Join(name, " "))
+	}
+	return nil
+}
+
+// sendInfo reports each line of a completed depth, numbering them with
+// multipv only when more than one was asked for.
+func (s *Session) sendInfo(result engine.SearchResult) {
+	millis := result.Time.Milliseconds()
+	nps := uint64(0)
+	if millis > 0 {
+		nps = result.Nodes * 1000 / uint64(millis)
+	}
+
+	for i, pvLine := range result.Lines {
+		var line strings.Builder
+		fmt.Fprintf(&line, "info depth %d", result.Depth)
+		if s.multiPV > 1 {
+			fmt.Fprintf(&line, " multipv %d", i+1)
+		}
+		if pvLine.Mate != 0 {
+			fmt.Fprintf(&line, " score mate %d", pvLine.Mate)
+		} else {
+			fmt.Fprintf(&line, " score cp %d", pvLine.Score)
+		}
+		fmt.Fprintf(&line, " nodes %d nps %d hashfull %d time %d", result.Nodes, nps, result.Hashfull, millis)
+
+		if len(pvLine.PV) > 0 {
+			line.WriteString(" pv")
+			for _, move := range pvLine.PV {
+				line.WriteString(" " + move.ToString())
+			}
+		}
+		s.send("%s", line.String())
+	}
+}
+
+func (s *Session) sendBestMove(result engine.SearchResult) {
+	if result.BestMove == 0 {
+		s.send("bestmove 0000")
+		return
+	}
+	if len(result.PV) > 1 {
+		s.send("bestmove %s ponder %s", result.BestMove.ToString(), result.PV[1].ToString())
+		return
+	}
+	s.send("bestmove %s", result.BestMove.ToString())
+}